@@ -2,19 +2,187 @@
 package core
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"regexp"
 )
 
 type Config struct {
-	ReadwiseToken     string
-	AnytypeAPIKey     string
-	AnytypeBaseURL    string
-	AnytypeVersion    string
-	TemplatePath      string
-	AnytypeTemplateID string
-	ObjectType        string
-	SpaceID           string
+	ReadwiseToken  string `json:"-"`
+	AnytypeAPIKey  string `json:"-"`
+	AnytypeBaseURL string `json:"-"`
+	AnytypeVersion string `json:"-"`
+	// Source is the provider books and highlights are read from, SourcePath its file or directory
+	Source            string `json:"source"`
+	SourcePath        string `json:"source_path"`
+	TemplatePath      string `json:"template"`
+	AnytypeTemplateID string `json:"anytype_template"`
+	ObjectType        string `json:"object_type"`
+	SpaceID           string `json:"space_id"`
+	NamePattern       string `json:"name_pattern"`
+	StatePath         string `json:"state_path"`
+	// Icons maps a Readwise category (books, articles, tweets, podcasts...) to an emoji
+	Icons map[string]string `json:"icons"`
+	// CoverImage sets where the book cover image goes: "icon", "cover", "both" or "" to disable
+	CoverImage    string `json:"cover_image"`
+	CoverProperty string `json:"cover_property"`
+	// OnDelete sets what happens to the object of a book deleted in Readwise: "archive", "flag" or "" to keep it
+	OnDelete        string `json:"on_delete"`
+	DeletedProperty string `json:"deleted_property"`
+	// HighlightObjects creates one object per highlight, linked to its book object
+	HighlightObjects    bool                  `json:"highlight_objects"`
+	HighlightObjectType string                `json:"highlight_object_type"`
+	HighlightProperties HighlightPropertyKeys `json:"highlight_properties"`
+	// AuthorObjects creates one object per author and links the books to them
+	AuthorObjects    bool   `json:"author_objects"`
+	AuthorObjectType string `json:"author_object_type"`
+	AuthorProperty   string `json:"author_property"`
+	// Collection is the name of the collection every synced book is added to
+	Collection            string `json:"collection"`
+	CollectionPerCategory bool   `json:"collection_per_category"`
+	// SyncTags syncs the Readwise tags into the multi-select property TagProperty
+	SyncTags    bool   `json:"sync_tags"`
+	TagProperty string `json:"tag_property"`
+	// NoteConventions interprets the Readwise note conventions (.h1, .tag, .c1) before rendering
+	NoteConventions bool `json:"note_conventions"`
+
+	// Sink is where rendered books are written: "anytype" (default), "markdown" to the OutputDir directory,
+	// or "anytype-import" to the BundlePath zip for the Anytype Markdown importer
+	Sink       string `json:"sink"`
+	OutputDir  string `json:"output_dir"`
+	BundlePath string `json:"bundle_path"`
+
+	// Mirror is the directory where every book and highlight read from the source is archived
+	Mirror string `json:"mirror"`
+
+	// EditedSince lists the highlights edited since this date in the report, as YYYY-MM-DD or RFC 3339
+	EditedSince string `json:"edited_since"`
+
+	// Sources are the providers merged by the composite source, the first ones take precedence
+	Sources []SourceConfig `json:"sources"`
+
+	// HighlightFilter drops highlights before rendering
+	HighlightFilter HighlightFilter `json:"highlight_filter"`
+
+	// Properties sets extra properties on book objects from templates
+	Properties []PropertyMapping `json:"properties"`
+
+	Rules []RoutingRule `json:"rules"`
+}
+
+// HighlightFilter lists the conditions a highlight must meet to be synced.
+// Discarded highlights are dropped unless IncludeDiscarded is set; empty conditions are ignored.
+type HighlightFilter struct {
+	IncludeDiscarded bool     `json:"include_discarded"`
+	IncludeTags      []string `json:"include_tags"`
+	ExcludeTags      []string `json:"exclude_tags"`
+	Colors           []string `json:"colors"`
+	ExcludeColors    []string `json:"exclude_colors"`
+	MinLength        int      `json:"min_length"`
+	// Since and Until bound the highlight date, as YYYY-MM-DD or RFC 3339
+	Since        string `json:"since"`
+	Until        string `json:"until"`
+	TextRegex    string `json:"text_regex"`
+	ExcludeRegex string `json:"exclude_regex"`
+}
+
+// SourceConfig is one of the providers merged by the composite source
+type SourceConfig struct {
+	Type string `json:"type"`
+	Path string `json:"path"`
+}
+
+// PropertyMapping sets the property Key of book objects to the rendered Value template.
+// Format is one of text, url, number, date or checkbox; empty values are not set.
+type PropertyMapping struct {
+	Key    string `json:"key"`
+	Format string `json:"format"`
+	Value  string `json:"value"`
+}
+
+// Property mapping formats
+const (
+	PropertyFormatText     = "text"
+	PropertyFormatURL      = "url"
+	PropertyFormatNumber   = "number"
+	PropertyFormatDate     = "date"
+	PropertyFormatCheckbox = "checkbox"
+)
+
+// HighlightPropertyKeys are the property keys used on highlight objects.
+// BookHighlights is set on the book object and left out when empty.
+type HighlightPropertyKeys struct {
+	Text           string `json:"text"`
+	Note           string `json:"note"`
+	Location       string `json:"location"`
+	Color          string `json:"color"`
+	HighlightedAt  string `json:"highlighted_at"`
+	Book           string `json:"book"`
+	BookHighlights string `json:"book_highlights"`
+}
+
+// WithDefaults fills the empty keys with the default property keys
+func (k HighlightPropertyKeys) WithDefaults() HighlightPropertyKeys {
+	k.Text = orDefault(k.Text, "highlight_text")
+	k.Note = orDefault(k.Note, "highlight_note")
+	k.Location = orDefault(k.Location, "location")
+	k.Color = orDefault(k.Color, "color")
+	k.HighlightedAt = orDefault(k.HighlightedAt, "highlighted_at")
+	k.Book = orDefault(k.Book, "book")
+	return k
+}
+
+func orDefault(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+// Sinks
+const (
+	SinkAnytype       = "anytype"
+	SinkMarkdown      = "markdown"
+	SinkAnytypeImport = "anytype-import"
+)
+
+// Cover image modes
+const (
+	CoverImageIcon  = "icon"
+	CoverImageCover = "cover"
+	CoverImageBoth  = "both"
+)
+
+// Deletion modes
+const (
+	OnDeleteArchive = "archive"
+	OnDeleteFlag    = "flag"
+)
+
+// RoutingRule sends the books matching Match to a specific space, object type and template.
+// Empty target fields fall back to the global configuration.
+type RoutingRule struct {
+	Name              string    `json:"name"`
+	Match             RuleMatch `json:"match"`
+	SpaceID           string    `json:"space_id"`
+	ObjectType        string    `json:"object_type"`
+	TemplatePath      string    `json:"template"`
+	AnytypeTemplateID string    `json:"anytype_template"`
+	NamePattern       string    `json:"name_pattern"`
+	Collection        string    `json:"collection"`
+	Icon              string    `json:"icon"`
+	Skip              bool      `json:"skip"`
+}
+
+// RuleMatch lists the conditions a book must meet for a rule to apply.
+// Every non-empty condition must match; a list matches if any of its values does.
+type RuleMatch struct {
+	Categories []string `json:"category"`
+	Sources    []string `json:"source"`
+	Tags       []string `json:"tags"`
+	Authors    []string `json:"author"`
+	TitleRegex string   `json:"title_regex"`
 }
 
 func GetEnvOrDefault(key, defaultValue string) string {
@@ -24,12 +192,75 @@ func GetEnvOrDefault(key, defaultValue string) string {
 	return defaultValue
 }
 
+// LoadConfigFile reads a JSON configuration file on top of the given config.
+// Only the keys present in the file are overwritten.
+func LoadConfigFile(path string, config *Config) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err := json.Unmarshal(content, config); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Sources
+const (
+	SourceReadwise        = "readwise"
+	SourceKindleClippings = "kindle"
+	SourceKOReader        = "koreader"
+	SourceKobo            = "kobo"
+	SourceReadwiseCSV     = "readwise-csv"
+	SourceHypothesis      = "hypothesis"
+	SourceZotero          = "zotero"
+	SourcePDF             = "pdf"
+	SourceJSON            = "json"
+	SourceMirror          = "mirror"
+	SourceComposite       = "composite"
+)
+
+// TextDerivedIDSources are the sources whose highlight IDs derive from the highlight text,
+// so that a highlight edited in the source comes back with a new ID
+var TextDerivedIDSources = []string{SourceKindleClippings, SourceKOReader, SourceReadwiseCSV}
+
 func ValidateConfig(config *Config) error {
-	if config.ReadwiseToken == "" {
-		return fmt.Errorf("READWISE_TOKEN environment variable is required")
+	if config.Source == SourceComposite {
+		if len(config.Sources) == 0 {
+			return fmt.Errorf("the composite source requires a list of sources")
+		}
+		for i, source := range config.Sources {
+			if source.Type == SourceComposite {
+				return fmt.Errorf("source %d: composite sources can't be nested", i+1)
+			}
+			if err := validateSource(config, source.Type, source.Path); err != nil {
+				return fmt.Errorf("source %d: %w", i+1, err)
+			}
+		}
+	} else if err := validateSource(config, config.Source, config.SourcePath); err != nil {
+		return err
 	}
-	if config.AnytypeAPIKey == "" {
-		return fmt.Errorf("ANYTYPE_API_KEY environment variable is required")
+	switch config.Sink {
+	case "", SinkAnytype:
+		if config.AnytypeAPIKey == "" {
+			return fmt.Errorf("ANYTYPE_API_KEY environment variable is required")
+		}
+	case SinkMarkdown:
+		if config.OutputDir == "" {
+			return fmt.Errorf("output_dir is required for the markdown sink")
+		}
+		if err := validateFileSink(config); err != nil {
+			return err
+		}
+	case SinkAnytypeImport:
+		if config.BundlePath == "" {
+			return fmt.Errorf("bundle_path is required for the anytype-import sink")
+		}
+		if err := validateFileSink(config); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid sink %q: must be anytype, markdown or anytype-import", config.Sink)
 	}
 
 	// Ensure at least one template option is provided
@@ -43,5 +274,106 @@ func ValidateConfig(config *Config) error {
 		}
 	}
 
+	switch config.CoverImage {
+	case "", CoverImageIcon, CoverImageCover, CoverImageBoth:
+	default:
+		return fmt.Errorf("invalid cover_image %q: must be icon, cover or both", config.CoverImage)
+	}
+	if (config.CoverImage == CoverImageCover || config.CoverImage == CoverImageBoth) && config.CoverProperty == "" {
+		return fmt.Errorf("cover_property is required to set cover images")
+	}
+
+	switch config.OnDelete {
+	case "", OnDeleteArchive, OnDeleteFlag:
+	default:
+		return fmt.Errorf("invalid on_delete %q: must be archive or flag", config.OnDelete)
+	}
+	if config.OnDelete == OnDeleteFlag && config.DeletedProperty == "" {
+		return fmt.Errorf("deleted_property is required to flag deleted books")
+	}
+
+	for i, mapping := range config.Properties {
+		if mapping.Key == "" {
+			return fmt.Errorf("property mapping %d: key is required", i+1)
+		}
+		switch mapping.Format {
+		case PropertyFormatText, PropertyFormatURL, PropertyFormatNumber, PropertyFormatDate, PropertyFormatCheckbox:
+		default:
+			return fmt.Errorf("property mapping %s: invalid format %q", mapping.Key, mapping.Format)
+		}
+	}
+
+	for i, rule := range config.Rules {
+		if err := validateRule(rule); err != nil {
+			return fmt.Errorf("rule %d (%s): %w", i+1, rule.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// validateFileSink rejects the options that need the Anytype API when writing files.
+// The import bundle names its index after the collection.
+func validateFileSink(config *Config) error {
+	options := []struct {
+		name string
+		set  bool
+	}{
+		{"anytype_template", config.AnytypeTemplateID != ""},
+		{"cover_image", config.CoverImage != ""},
+		{"highlight_objects", config.HighlightObjects},
+		{"author_objects", config.AuthorObjects},
+		{"collection", config.Collection != "" && config.Sink == SinkMarkdown},
+		{"sync_tags", config.SyncTags},
+	}
+	for _, option := range options {
+		if option.set {
+			return fmt.Errorf("%s requires the anytype sink", option.name)
+		}
+	}
+
+	for i, rule := range config.Rules {
+		if rule.AnytypeTemplateID != "" || rule.Collection != "" {
+			return fmt.Errorf("rule %d (%s): anytype_template and collection require the anytype sink", i+1, rule.Name)
+		}
+	}
+	return nil
+}
+
+// validateSource checks that a source is known and has what it needs to be read
+func validateSource(config *Config, source, path string) error {
+	switch source {
+	case "", SourceReadwise:
+		if config.ReadwiseToken == "" {
+			return fmt.Errorf("READWISE_TOKEN environment variable is required")
+		}
+	case SourceKindleClippings, SourceKOReader, SourceKobo, SourceReadwiseCSV, SourceHypothesis, SourceZotero, SourcePDF, SourceJSON, SourceMirror:
+		if path == "" {
+			return fmt.Errorf("a source path is required for the %s source", source)
+		}
+		// The JSON source reads standard input from "-"
+		if source == SourceJSON && path == "-" {
+			return nil
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return fmt.Errorf("source not found: %s", path)
+		}
+	default:
+		return fmt.Errorf("unknown source %q", source)
+	}
+	return nil
+}
+
+func validateRule(rule RoutingRule) error {
+	if rule.Match.TitleRegex != "" {
+		if _, err := regexp.Compile(rule.Match.TitleRegex); err != nil {
+			return fmt.Errorf("invalid title_regex: %w", err)
+		}
+	}
+	if rule.TemplatePath != "" {
+		if _, err := os.Stat(rule.TemplatePath); os.IsNotExist(err) {
+			return fmt.Errorf("template file not found: %s", rule.TemplatePath)
+		}
+	}
 	return nil
 }
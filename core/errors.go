@@ -0,0 +1,98 @@
+// errors.go
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Error kinds shared by the API clients, to be checked with errors.Is
+var (
+	ErrUnauthorized      = errors.New("unauthorized")
+	ErrNotFound          = errors.New("not found")
+	ErrRateLimited       = errors.New("rate limited")
+	ErrValidation        = errors.New("validation failed")
+	ErrServerUnavailable = errors.New("server unavailable")
+)
+
+// APIError is a decoded error response of an external API.
+// Use errors.As to access the details, e.g. the invalid fields of an ErrValidation.
+type APIError struct {
+	Service    string
+	StatusCode int
+	Code       string
+	Message    string
+	// Fields holds the messages of every invalid field, if the API reported them
+	Fields map[string][]string
+	// RetryAfter is the delay requested by the API before retrying, if any
+	RetryAfter time.Duration
+	// Hint is an actionable suggestion shown to the user
+	Hint string
+}
+
+func (e *APIError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s API: %s (status %d)", e.Service, e.Kind(), e.StatusCode)
+	if e.Message != "" {
+		fmt.Fprintf(&b, ": %s", strings.TrimSuffix(e.Message, "."))
+	}
+	if len(e.Fields) > 0 {
+		names := make([]string, 0, len(e.Fields))
+		for name := range e.Fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(&b, "; %s: %s", name, strings.Join(e.Fields[name], ", "))
+		}
+	}
+	if e.Hint != "" {
+		fmt.Fprintf(&b, ". %s", e.Hint)
+	}
+	return b.String()
+}
+
+// Unwrap returns the error kind matching the status code
+func (e *APIError) Unwrap() error {
+	return e.Kind()
+}
+
+// Kind returns the error kind matching the status code, or nil for unexpected statuses
+func (e *APIError) Kind() error {
+	switch {
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		return ErrUnauthorized
+	case e.StatusCode == http.StatusNotFound || e.StatusCode == http.StatusGone:
+		return ErrNotFound
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity:
+		return ErrValidation
+	case e.StatusCode >= 500:
+		return ErrServerUnavailable
+	}
+	return errUnexpectedStatus
+}
+
+var errUnexpectedStatus = errors.New("unexpected status")
+
+// ParseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func ParseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
@@ -0,0 +1,47 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TitleCase upper-cases the first letter of a value like the category "books"
+func TitleCase(value string) string {
+	first, size := utf8.DecodeRuneInString(value)
+	if size == 0 {
+		return value
+	}
+	return string(unicode.ToUpper(first)) + value[size:]
+}
+
+// Truncate shortens a value to at most n characters, the last one being an ellipsis when something was cut
+func Truncate(value string, n int) string {
+	if n <= 0 || utf8.RuneCountInString(value) <= n {
+		return value
+	}
+	runes := []rune(value)
+	return strings.TrimSpace(string(runes[:n-1])) + "…"
+}
+
+// ContainsFold tells whether values holds value, ignoring case
+func ContainsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseDate accepts plain dates (YYYY-MM-DD), RFC 3339 timestamps and the format Go prints time.Time values with
+func ParseDate(value string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", time.RFC3339, "2006-01-02 15:04:05.999999999 -0700 MST"} {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
@@ -0,0 +1,30 @@
+package core
+
+import "testing"
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		value string
+		n     int
+		want  string
+	}{
+		{"Deep Work", 20, "Deep Work"},
+		{"Deep Work", 9, "Deep Work"},
+		{"Deep Work", 6, "Deep…"},
+		{"Éléments de géométrie", 4, "Élé…"},
+		{"Deep Work", 0, "Deep Work"},
+	}
+	for _, test := range tests {
+		if got := Truncate(test.value, test.n); got != test.want {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", test.value, test.n, got, test.want)
+		}
+	}
+}
+
+func TestTitleCase(t *testing.T) {
+	for value, want := range map[string]string{"books": "Books", "éditions": "Éditions", "": ""} {
+		if got := TitleCase(value); got != want {
+			t.Errorf("TitleCase(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
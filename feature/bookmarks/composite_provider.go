@@ -0,0 +1,387 @@
+package bookmarks
+
+import (
+	"anytype-readwise/core"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// CompositeProvider implements the BookmarksProvider interface by merging several providers.
+// Books are matched across sources by ASIN, ISBN or title and author, and their highlights
+// are merged without the duplicates found in more than one source.
+type CompositeProvider struct {
+	sources []CompositeSource
+	loaded  bool
+	books   []ReadwiseBook
+	// members are the books of the sources merged into each book, by index in books
+	members [][]compositeMember
+	// byID finds the index of a merged book
+	byID map[int]int
+}
+
+// CompositeSource is a provider merged by a CompositeProvider, the name is recorded as the origin of its highlights
+type CompositeSource struct {
+	Name     string
+	Provider BookmarksProvider
+}
+
+// compositeMember is a book of one of the sources, part of a merged book
+type compositeMember struct {
+	source int
+	bookID int
+}
+
+// NewCompositeProvider creates a new CompositeProvider, the first sources take precedence
+// for the IDs and metadata of the merged books
+func NewCompositeProvider(sources ...CompositeSource) *CompositeProvider {
+	return &CompositeProvider{sources: sources}
+}
+
+// GetBooks returns the merged books of every source
+func (p *CompositeProvider) GetBooks() ([]ReadwiseBook, error) {
+	if p.loaded {
+		return p.books, nil
+	}
+
+	p.books = nil
+	p.members = nil
+	p.byID = make(map[int]int)
+	index := make(map[string]int)
+	for sourceIndex, source := range p.sources {
+		books, err := source.Provider.GetBooks()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get books from %s: %w", source.Name, err)
+		}
+
+		for _, book := range books {
+			sourceID := book.ID
+			keys := bookMatchKeys(book)
+			merged := -1
+			for _, key := range keys {
+				if i, ok := index[key]; ok && !p.hasSource(i, sourceIndex) {
+					merged = i
+					break
+				}
+			}
+
+			if merged < 0 {
+				merged = len(p.books)
+				if _, taken := p.byID[book.ID]; taken {
+					// Another source uses the same ID for a different book
+					book.ID = syntheticID(source.Name, strconv.Itoa(book.ID))
+				}
+				p.byID[book.ID] = merged
+				p.books = append(p.books, book)
+				p.members = append(p.members, nil)
+			} else {
+				mergeBook(&p.books[merged], book)
+			}
+			p.members[merged] = append(p.members[merged], compositeMember{source: sourceIndex, bookID: sourceID})
+			for _, key := range keys {
+				if _, ok := index[key]; !ok {
+					index[key] = merged
+				}
+			}
+		}
+	}
+
+	p.loaded = true
+	return p.books, nil
+}
+
+// GetHighlights returns the highlights of a merged book, from every source it was found in
+func (p *CompositeProvider) GetHighlights(bookID int) ([]Highlight, error) {
+	if _, err := p.GetBooks(); err != nil {
+		return nil, err
+	}
+	index, ok := p.byID[bookID]
+	if !ok {
+		return nil, fmt.Errorf("%w: book %d", core.ErrNotFound, bookID)
+	}
+
+	merged := []Highlight{}
+	var keys []highlightKey
+	for m, member := range p.members[index] {
+		source := p.sources[member.source]
+		highlights, err := source.Provider.GetHighlights(member.bookID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get highlights from %s: %w", source.Name, err)
+		}
+
+		// Highlights of the same member are never duplicates, a book can hold the same passage twice
+		from := len(merged)
+		for _, highlight := range highlights {
+			highlight.BookID = bookID
+			if highlight.Origin == "" {
+				highlight.Origin = source.Name
+			}
+			key := newHighlightKey(highlight.Text)
+			if i := findDuplicateHighlight(keys[:from], key, m); i >= 0 {
+				mergeHighlight(&merged[i], highlight)
+				keys[i].members[m] = true
+				continue
+			}
+			key.members = map[int]bool{m: true}
+			merged = append(merged, highlight)
+			keys = append(keys, key)
+		}
+	}
+	return merged, nil
+}
+
+func (p *CompositeProvider) hasSource(merged int, source int) bool {
+	for _, member := range p.members[merged] {
+		if member.source == source {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeBook completes a merged book with the metadata of the same book from another source
+func mergeBook(book *ReadwiseBook, other ReadwiseBook) {
+	fill := func(value *string, otherValue string) {
+		if *value == "" {
+			*value = otherValue
+		}
+	}
+	fill(&book.ReadableTitle, other.ReadableTitle)
+	fill(&book.Author, other.Author)
+	fill(&book.CoverImageURL, other.CoverImageURL)
+	fill(&book.SourceURL, other.SourceURL)
+	fill(&book.UniqueURL, other.UniqueURL)
+	fill(&book.ReadwiseURL, other.ReadwiseURL)
+	fill(&book.ASIN, other.ASIN)
+	fill(&book.ISBN, other.ISBN)
+	fill(&book.DocumentNote, other.DocumentNote)
+	fill(&book.Summary, other.Summary)
+	fill(&book.Language, other.Language)
+	fill(&book.DOI, other.DOI)
+	fill(&book.CitationKey, other.CitationKey)
+	if book.Year == 0 {
+		book.Year = other.Year
+	}
+
+	book.NumHighlights += other.NumHighlights
+	if other.LastHighlight.After(book.LastHighlight) {
+		book.LastHighlight = other.LastHighlight
+	}
+	if other.Updated.After(book.Updated) {
+		book.Updated = other.Updated
+	}
+	book.Tags = mergeTags(book.Tags, other.AllTags())
+}
+
+// mergeHighlight completes a highlight with the note, tags and position of its duplicate
+func mergeHighlight(highlight *Highlight, other Highlight) {
+	if highlight.Note == "" {
+		highlight.Note = other.Note
+	}
+	if highlight.Color == "" {
+		highlight.Color = other.Color
+	}
+	if highlight.Chapter == "" {
+		highlight.Chapter = other.Chapter
+	}
+	if highlight.PageLabel == "" {
+		highlight.PageLabel = other.PageLabel
+	}
+	if other.Updated.After(highlight.Updated) {
+		highlight.Updated = other.Updated
+	}
+	highlight.IsFavorite = highlight.IsFavorite || other.IsFavorite
+	highlight.Tags = mergeTags(highlight.Tags, other.Tags)
+}
+
+// mergeTags adds the tags of other that are not in tags yet, by name
+func mergeTags(tags []Tag, other []Tag) []Tag {
+	for _, tag := range other {
+		duplicate := false
+		for _, existing := range tags {
+			if strings.EqualFold(existing.Name, tag.Name) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// MatchKeys returns the keys identifying a book across sources: its ASIN, ISBN, and title with author surname
+func (b ReadwiseBook) MatchKeys() []string {
+	return bookMatchKeys(b)
+}
+
+// bookMatchKeys returns the keys identifying a book across sources, the most reliable first
+func bookMatchKeys(book ReadwiseBook) []string {
+	var keys []string
+	asin := strings.ToUpper(strings.TrimSpace(book.ASIN))
+	if asin != "" {
+		keys = append(keys, "asin:"+asin)
+	}
+	// The ASIN of a printed book is its ISBN-10
+	for _, isbn := range []string{book.ISBN, asin} {
+		if normalized := normalizeISBN(isbn); normalized != "" {
+			keys = append(keys, "isbn:"+normalized)
+		}
+	}
+	if title := matchTitle(book.Title); title != "" {
+		keys = append(keys, "title:"+title+"|"+authorSurname(book.Author))
+	}
+	return keys
+}
+
+// normalizeISBN returns the ISBN-13 of an ISBN-10 or ISBN-13, or "" when the value is not an ISBN
+func normalizeISBN(value string) string {
+	var digits []byte
+	for _, r := range strings.ToUpper(value) {
+		if (r >= '0' && r <= '9') || r == 'X' {
+			digits = append(digits, byte(r))
+		}
+	}
+
+	switch {
+	case len(digits) == 13 && !strings.ContainsRune(string(digits), 'X'):
+		return string(digits)
+	case len(digits) == 10 && !strings.ContainsRune(string(digits[:9]), 'X'):
+		isbn := "978" + string(digits[:9])
+		sum := 0
+		for i, d := range isbn {
+			weight := 1
+			if i%2 == 1 {
+				weight = 3
+			}
+			sum += int(d-'0') * weight
+		}
+		return isbn + string(rune('0'+(10-sum%10)%10))
+	}
+	return ""
+}
+
+// matchTitle reduces a title to its main words, without subtitle, series or edition
+func matchTitle(title string) string {
+	if main, _, found := strings.Cut(title, ":"); found && strings.TrimSpace(main) != "" {
+		title = main
+	}
+	var b strings.Builder
+	depth := 0
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case r == '(' || r == '[':
+			depth++
+		case r == ')' || r == ']':
+			if depth > 0 {
+				depth--
+			}
+		case depth > 0:
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		default:
+			b.WriteByte(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// authorSurname returns the lowercased surname of the first author,
+// written either "First Last" or "Last, First"
+func authorSurname(author string) string {
+	first := author
+	for _, separator := range []string{";", "&", " and ", "\n"} {
+		first, _, _ = strings.Cut(first, separator)
+	}
+
+	name := first
+	if last, _, found := strings.Cut(first, ","); found {
+		if len(strings.Fields(last)) == 1 {
+			return normalizeKey(last)
+		}
+		// "First Last, Other Author"
+		name = last
+	}
+	words := strings.Fields(name)
+	if len(words) == 0 {
+		return ""
+	}
+	return strings.ToLower(words[len(words)-1])
+}
+
+// highlightKey is the normalized text of a highlight and its bigrams, computed once to compare it
+// with every other highlight, and the members it was merged from
+type highlightKey struct {
+	text    string
+	bigrams map[string]int
+	members map[int]bool
+}
+
+func newHighlightKey(text string) highlightKey {
+	normalized := matchText(text)
+	return highlightKey{text: normalized, bigrams: bigrams(normalized)}
+}
+
+// findDuplicateHighlight returns the index of the highlight with the same text not merged from the member yet, or -1
+func findDuplicateHighlight(keys []highlightKey, key highlightKey, member int) int {
+	for i, existing := range keys {
+		if !existing.members[member] && similarText(existing, key) {
+			return i
+		}
+	}
+	return -1
+}
+
+// matchText keeps the lowercased words of a text, ignoring punctuation, quotes and whitespace
+func matchText(text string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// similarText tells whether two highlights have the same text: equal, one nearly containing
+// the other, as when a device truncates long highlights, or with 90% of their bigrams in common
+func similarText(a, b highlightKey) bool {
+	if a.text == "" || b.text == "" {
+		return false
+	}
+	if a.text == b.text {
+		return true
+	}
+	shorter, longer := a.text, b.text
+	if len(shorter) > len(longer) {
+		shorter, longer = longer, shorter
+	}
+	if strings.Contains(longer, shorter) && len(shorter)*5 >= len(longer)*4 {
+		return true
+	}
+	return diceCoefficient(a.bigrams, b.bigrams) >= 0.9
+}
+
+// bigrams counts the character bigrams of a string
+func bigrams(s string) map[string]int {
+	runes := []rune(s)
+	counts := make(map[string]int)
+	for i := 0; i+1 < len(runes); i++ {
+		counts[string(runes[i:i+2])]++
+	}
+	return counts
+}
+
+// diceCoefficient measures the similarity of two strings by their character bigrams
+func diceCoefficient(first, second map[string]int) float64 {
+	total, common := 0, 0
+	for bigram, count := range first {
+		total += count
+		common += min(count, second[bigram])
+	}
+	for _, count := range second {
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+	return 2 * float64(common) / float64(total)
+}
@@ -0,0 +1,88 @@
+package bookmarks
+
+import (
+	"anytype-readwise/core"
+	"errors"
+	"testing"
+)
+
+// staticProvider serves fixed books and highlights
+type staticProvider struct {
+	books      []ReadwiseBook
+	highlights map[int][]Highlight
+}
+
+func (p staticProvider) GetBooks() ([]ReadwiseBook, error) {
+	return p.books, nil
+}
+
+func (p staticProvider) GetHighlights(bookID int) ([]Highlight, error) {
+	return p.highlights[bookID], nil
+}
+
+func TestCompositeProviderIDs(t *testing.T) {
+	kindle := staticProvider{
+		books:      []ReadwiseBook{{ID: 1, Title: "Deep Work", Author: "Cal Newport"}},
+		highlights: map[int][]Highlight{1: {{ID: 10, Text: "Focus is rare"}}},
+	}
+	// The same ID for another book, and the same book under another ID
+	kobo := staticProvider{
+		books: []ReadwiseBook{{ID: 1, Title: "Walden", Author: "Henry David Thoreau"}, {ID: 2, Title: "Deep Work", Author: "Newport, Cal"}},
+		highlights: map[int][]Highlight{
+			1: {{ID: 20, Text: "Simplify, simplify"}},
+			2: {{ID: 21, Text: "Focus is rare", Note: "from the Kobo"}},
+		},
+	}
+	provider := NewCompositeProvider(CompositeSource{Name: "kindle", Provider: kindle}, CompositeSource{Name: "kobo", Provider: kobo})
+
+	books, err := provider.GetBooks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(books) != 2 || books[0].ID != 1 || books[1].ID == 1 {
+		t.Fatalf("got books %+v, want the merged Deep Work and Walden under distinct IDs", books)
+	}
+
+	deepWork, err := provider.GetHighlights(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deepWork) != 1 || deepWork[0].Note != "from the Kobo" {
+		t.Errorf("got %+v, want the highlight merged from both sources", deepWork)
+	}
+	walden, err := provider.GetHighlights(books[1].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(walden) != 1 || walden[0].ID != 20 || walden[0].BookID != books[1].ID {
+		t.Errorf("got %+v, want the Walden highlight", walden)
+	}
+
+	if _, err := provider.GetHighlights(3); !errors.Is(err, core.ErrNotFound) {
+		t.Errorf("got error %v for an unknown book, want ErrNotFound", err)
+	}
+}
+
+func TestCompositeProviderDuplicates(t *testing.T) {
+	// The Kindle holds the same passage twice, the Kobo once
+	kindle := staticProvider{
+		books: []ReadwiseBook{{ID: 1, Title: "Deep Work", Author: "Cal Newport"}},
+		highlights: map[int][]Highlight{1: {
+			{ID: 10, Text: "Focus is rare."},
+			{ID: 11, Text: "Focus is rare"},
+		}},
+	}
+	kobo := staticProvider{
+		books:      []ReadwiseBook{{ID: 1, Title: "Deep Work", Author: "Cal Newport"}},
+		highlights: map[int][]Highlight{1: {{ID: 20, Text: "“Focus is rare”", Note: "from the Kobo"}}},
+	}
+	provider := NewCompositeProvider(CompositeSource{Name: "kindle", Provider: kindle}, CompositeSource{Name: "kobo", Provider: kobo})
+
+	highlights, err := provider.GetHighlights(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(highlights) != 2 || highlights[0].Note != "from the Kobo" || highlights[1].Note != "" {
+		t.Errorf("got %+v, want both Kindle highlights, the first merged with the Kobo one", highlights)
+	}
+}
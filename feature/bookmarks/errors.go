@@ -0,0 +1,68 @@
+package bookmarks
+
+import (
+	"anytype-readwise/core"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxErrorBodySize limits how much of an error response is read
+const maxErrorBodySize = 64 << 10
+
+// decodeReadwiseError turns an unsuccessful Readwise API response into a *core.APIError.
+// Readwise reports errors as {"detail": "..."} or, for invalid parameters, as {"field": ["message"]}.
+func decodeReadwiseError(resp *http.Response) error {
+	apiErr := &core.APIError{
+		Service:    "readwise",
+		StatusCode: resp.StatusCode,
+		RetryAfter: core.ParseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	var payload map[string]json.RawMessage
+	if err := json.Unmarshal(body, &payload); err == nil {
+		for key, raw := range payload {
+			if key == "detail" {
+				var detail string
+				if json.Unmarshal(raw, &detail) == nil {
+					apiErr.Message = detail
+				}
+				continue
+			}
+			if messages := decodeFieldMessages(raw); len(messages) > 0 {
+				if apiErr.Fields == nil {
+					apiErr.Fields = make(map[string][]string)
+				}
+				apiErr.Fields[key] = messages
+			}
+		}
+	} else {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+
+	switch {
+	case errors.Is(apiErr, core.ErrUnauthorized):
+		apiErr.Hint = "Check that READWISE_TOKEN is valid (https://readwise.io/access_token)"
+	case errors.Is(apiErr, core.ErrRateLimited):
+		apiErr.Hint = fmt.Sprintf("Readwise asked to wait %s before retrying", apiErr.RetryAfter)
+	}
+
+	return apiErr
+}
+
+// decodeFieldMessages decodes the messages of a field, given either as a string or a list of strings
+func decodeFieldMessages(raw json.RawMessage) []string {
+	var messages []string
+	if err := json.Unmarshal(raw, &messages); err == nil {
+		return messages
+	}
+	var message string
+	if err := json.Unmarshal(raw, &message); err == nil {
+		return []string{message}
+	}
+	return nil
+}
@@ -0,0 +1,282 @@
+package bookmarks
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JSONProvider implements the BookmarksProvider interface by reading books and highlights
+// from a JSON array or NDJSON stream following schema/bookmarks.schema.json
+type JSONProvider struct {
+	path string
+	localLibrary
+}
+
+// NewJSONProvider creates a new JSONProvider for the given file, or standard input when the path is "-"
+func NewJSONProvider(path string) *JSONProvider {
+	p := &JSONProvider{path: path}
+	p.load = p.parse
+	return p
+}
+
+// maxJSONProblems is the number of invalid records reported before giving up
+const maxJSONProblems = 20
+
+// jsonRecord is a record of the input, either a book or a highlight of a book defined elsewhere
+type jsonRecord struct {
+	line int
+	raw  json.RawMessage
+}
+
+// jsonProblem is an invalid record, reported with its line
+type jsonProblem struct {
+	line    int
+	message string
+}
+
+// jsonHighlight is a top-level highlight waiting for its book
+type jsonHighlight struct {
+	line      int
+	highlight Highlight
+}
+
+func (p *JSONProvider) parse() (*libraryBuilder, error) {
+	var content []byte
+	var err error
+	if p.path == "-" {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(p.path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JSON input: %w", err)
+	}
+
+	records, err := splitJSONRecords(content)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := newLibraryBuilder()
+	var problems []jsonProblem
+	var pending []jsonHighlight
+	for _, record := range records {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(record.raw, &fields); err != nil {
+			problems = append(problems, jsonProblem{record.line, "a record must be an object"})
+			continue
+		}
+
+		switch {
+		case fields["title"] != nil:
+			var book ReadwiseBook
+			if problem := decodeJSONRecord(record, &book); problem != nil {
+				problems = append(problems, *problem)
+				continue
+			}
+			if problem := p.addBook(builder, book, record.line); problem != nil {
+				problems = append(problems, *problem)
+			}
+		case fields["text"] != nil:
+			var highlight Highlight
+			if problem := decodeJSONRecord(record, &highlight); problem != nil {
+				problems = append(problems, *problem)
+				continue
+			}
+			pending = append(pending, jsonHighlight{line: record.line, highlight: highlight})
+		default:
+			problems = append(problems, jsonProblem{record.line, "a record needs a title (book) or a text (highlight)"})
+		}
+		if len(problems) >= maxJSONProblems {
+			break
+		}
+	}
+
+	// Highlights may come before the book they belong to
+	for _, item := range pending {
+		if len(problems) >= maxJSONProblems {
+			break
+		}
+		if item.highlight.BookID == 0 {
+			problems = append(problems, jsonProblem{item.line, "a highlight outside of a book needs a book_id"})
+			continue
+		}
+		if _, ok := builder.books[item.highlight.BookID]; !ok {
+			problems = append(problems, jsonProblem{item.line, fmt.Sprintf("unknown book_id %d", item.highlight.BookID)})
+			continue
+		}
+		if problem := addJSONHighlight(builder, item.highlight.BookID, item.highlight, item.line); problem != nil {
+			problems = append(problems, *problem)
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.SliceStable(problems, func(i, j int) bool { return problems[i].line < problems[j].line })
+		lines := make([]string, 0, len(problems))
+		for _, problem := range problems {
+			lines = append(lines, fmt.Sprintf("line %d: %s", problem.line, problem.message))
+		}
+		return nil, fmt.Errorf("invalid JSON input:\n  %s", strings.Join(lines, "\n  "))
+	}
+	return builder, nil
+}
+
+// addBook validates a book and registers it with its nested highlights
+func (p *JSONProvider) addBook(builder *libraryBuilder, book ReadwiseBook, line int) *jsonProblem {
+	if strings.TrimSpace(book.Title) == "" {
+		return &jsonProblem{line, "title must not be empty"}
+	}
+	if book.ID < 0 {
+		return &jsonProblem{line, "id must be positive"}
+	}
+	if book.ID == 0 {
+		book.ID = syntheticID("json", normalizeKey(book.Title), normalizeKey(book.Author))
+	}
+	if book.Category == "" {
+		book.Category = "books"
+	}
+	if book.Source == "" {
+		book.Source = "json"
+	}
+
+	highlights := book.Highlights
+	book.Highlights = nil
+	added := builder.addBook(book)
+	for i, highlight := range highlights {
+		if highlight.BookID != 0 && highlight.BookID != added.ID {
+			return &jsonProblem{line, fmt.Sprintf("highlight %d: book_id %d doesn't match the book id %d", i+1, highlight.BookID, added.ID)}
+		}
+		if problem := addJSONHighlight(builder, added.ID, highlight, line); problem != nil {
+			return problem
+		}
+	}
+	return nil
+}
+
+// addJSONHighlight validates a highlight and adds it to its book
+func addJSONHighlight(builder *libraryBuilder, bookID int, highlight Highlight, line int) *jsonProblem {
+	if strings.TrimSpace(highlight.Text) == "" {
+		return &jsonProblem{line, "highlight text must not be empty"}
+	}
+	if highlight.ID < 0 {
+		return &jsonProblem{line, "highlight id must be positive"}
+	}
+	if highlight.ID == 0 {
+		highlight.ID = syntheticID("json", strconv.Itoa(bookID), highlight.Text)
+	}
+	if highlight.CreatedAt.IsZero() {
+		highlight.CreatedAt = highlight.HighlightedAt
+	}
+	if highlight.Updated.IsZero() {
+		highlight.Updated = highlight.CreatedAt
+	}
+	builder.addHighlight(bookID, highlight)
+	return nil
+}
+
+// splitJSONRecords returns the elements of a JSON array, or the values of an NDJSON
+// or concatenated JSON stream, with the line each one starts on
+func splitJSONRecords(content []byte) ([]jsonRecord, error) {
+	content = bytes.TrimPrefix(content, []byte("\ufeff"))
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	isArray := bytes.HasPrefix(bytes.TrimSpace(content), []byte("["))
+	if isArray {
+		if _, err := decoder.Token(); err != nil {
+			return nil, jsonSyntaxError(content, err)
+		}
+	}
+
+	var records []jsonRecord
+	for {
+		if isArray && !decoder.More() {
+			break
+		}
+		start := decoder.InputOffset()
+		var raw json.RawMessage
+		err := decoder.Decode(&raw)
+		if err == io.EOF && !isArray {
+			break
+		}
+		if err != nil {
+			return nil, jsonSyntaxError(content, err)
+		}
+		records = append(records, jsonRecord{line: lineAt(content, skipJSONSpace(content, int(start))), raw: raw})
+	}
+	if isArray {
+		if _, err := decoder.Token(); err != nil {
+			return nil, jsonSyntaxError(content, err)
+		}
+	}
+	return records, nil
+}
+
+// decodeJSONRecord decodes a record, rejecting the fields that are not part of the schema
+func decodeJSONRecord(record jsonRecord, value interface{}) *jsonProblem {
+	decoder := json.NewDecoder(bytes.NewReader(record.raw))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(value)
+	if err == nil {
+		return nil
+	}
+
+	line := record.line
+	var typeError *json.UnmarshalTypeError
+	if errors.As(err, &typeError) {
+		line += bytes.Count(record.raw[:min(int(typeError.Offset), len(record.raw))], []byte("\n"))
+		return &jsonProblem{line, fmt.Sprintf("%s must be %s, got %s", typeError.Field, jsonTypeName(typeError.Type.Kind().String()), typeError.Value)}
+	}
+	var timeError *time.ParseError
+	if errors.As(err, &timeError) {
+		return &jsonProblem{line, fmt.Sprintf("invalid date %q, dates must be RFC 3339 like 2024-05-01T10:00:00Z", timeError.Value)}
+	}
+	return &jsonProblem{line, strings.TrimPrefix(err.Error(), "json: ")}
+}
+
+// jsonTypeName names a Go kind the way the schema does
+func jsonTypeName(kind string) string {
+	switch {
+	case strings.HasPrefix(kind, "int"), strings.HasPrefix(kind, "uint"):
+		return "an integer"
+	case strings.HasPrefix(kind, "float"):
+		return "a number"
+	case kind == "bool":
+		return "a boolean"
+	case kind == "slice":
+		return "an array"
+	case kind == "struct", kind == "map":
+		return "an object"
+	}
+	return "a " + kind
+}
+
+// jsonSyntaxError locates a syntax error in the input
+func jsonSyntaxError(content []byte, err error) error {
+	var syntaxError *json.SyntaxError
+	if errors.As(err, &syntaxError) {
+		return fmt.Errorf("invalid JSON input: line %d: %s", lineAt(content, int(syntaxError.Offset)), syntaxError.Error())
+	}
+	if err == io.ErrUnexpectedEOF || err == io.EOF {
+		return fmt.Errorf("invalid JSON input: unexpected end of input")
+	}
+	return fmt.Errorf("invalid JSON input: %w", err)
+}
+
+// lineAt returns the 1-based line of a byte offset
+func lineAt(content []byte, offset int) int {
+	return bytes.Count(content[:min(offset, len(content))], []byte("\n")) + 1
+}
+
+func skipJSONSpace(content []byte, offset int) int {
+	for offset < len(content) && strings.ContainsRune(" \t\r\n,", rune(content[offset])) {
+		offset++
+	}
+	return offset
+}
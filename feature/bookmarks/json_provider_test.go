@@ -0,0 +1,196 @@
+package bookmarks
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONProvider(t *testing.T) {
+	tests := []struct {
+		file       string
+		book       ReadwiseBook
+		highlights []Highlight
+	}{
+		{
+			// An array, with a highlight listed before its book
+			file: "books.json",
+			book: ReadwiseBook{ID: 7, Title: "Deep Work", Author: "Cal Newport", Category: "books"},
+			highlights: []Highlight{
+				{ID: 70, Text: "Focus is rare", Note: "Quote this", Location: 3, HighlightedAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Tags: []Tag{{Name: "focus"}}},
+				{Text: "A highlight listed before its book", Location: 12, HighlightedAt: time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			// NDJSON starting with a byte order mark, with a blank line
+			file: "books.ndjson",
+			book: ReadwiseBook{ID: 8, Title: "Walden", Author: "Henry David Thoreau", Category: "articles"},
+			highlights: []Highlight{
+				{ID: 80, Text: "Simplify, simplify", HighlightedAt: time.Date(2024, 5, 3, 10, 0, 0, 0, time.UTC)},
+				{ID: 81, Text: "I went to the woods"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.file, func(t *testing.T) {
+			provider := NewJSONProvider(filepath.Join("testdata", "json", test.file))
+			books, err := provider.GetBooks()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(books) != 1 {
+				t.Fatalf("got %d books, want 1", len(books))
+			}
+			book, want := books[0], test.book
+			if book.ID != want.ID || book.Title != want.Title || book.Author != want.Author || book.Category != want.Category || book.Source != "json" {
+				t.Errorf("got book %+v, want %+v", book, want)
+			}
+
+			highlights, err := provider.GetHighlights(book.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(highlights) != len(test.highlights) {
+				t.Fatalf("got %d highlights, want %d", len(highlights), len(test.highlights))
+			}
+			for i, want := range test.highlights {
+				got := highlights[i]
+				// Highlights without an id get one derived from their book and text
+				if want.ID == 0 && got.ID > 0 {
+					want.ID = got.ID
+				}
+				if got.ID != want.ID || got.Text != want.Text || got.Note != want.Note || got.Location != want.Location ||
+					!got.HighlightedAt.Equal(want.HighlightedAt) || !got.CreatedAt.Equal(want.HighlightedAt) || !got.Updated.Equal(want.HighlightedAt) ||
+					!reflect.DeepEqual(got.Tags, want.Tags) {
+					t.Errorf("got highlight %+v, want %+v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestJSONProviderProblems(t *testing.T) {
+	_, err := NewJSONProvider(filepath.Join("testdata", "json", "invalid.ndjson")).GetBooks()
+	want := strings.Join([]string{
+		"invalid JSON input:",
+		"line 2: unknown book_id 9",
+		"line 3: a highlight outside of a book needs a book_id",
+		"line 6: year must be an integer, got string",
+		"line 7: highlight 1: book_id 7 doesn't match the book id 8",
+		`line 8: unknown field "pages"`,
+		`line 9: invalid date "yesterday", dates must be RFC 3339 like 2024-05-01T10:00:00Z`,
+		"line 10: a record needs a title (book) or a text (highlight)",
+		"line 11: a record must be an object",
+	}, "\n  ")
+	if err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %s", err, want)
+	}
+}
+
+func TestJSONProviderSyntax(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "array",
+			content: "[\n  {\"title\": \"Deep Work\"},\n  {\"title\": }\n]",
+			want:    "invalid JSON input: line 3: invalid character '}' after array element",
+		},
+		{
+			name:    "ndjson",
+			content: "{\"title\": \"Deep Work\"}\n{\"title\": \"Walden\"",
+			want:    "invalid JSON input: unexpected end of input",
+		},
+		{
+			name:    "unclosed array",
+			content: "[{\"title\": \"Deep Work\"}",
+			want:    "invalid JSON input: line 1: unexpected end of JSON input",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "books.json")
+			if err := os.WriteFile(path, []byte(test.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := NewJSONProvider(path).GetBooks(); err == nil || err.Error() != test.want {
+				t.Errorf("got error %v, want %s", err, test.want)
+			}
+		})
+	}
+}
+
+func TestJSONProviderProblemLimit(t *testing.T) {
+	var content strings.Builder
+	for i := 0; i < maxJSONProblems+5; i++ {
+		content.WriteString("{\"text\": \"No book\"}\n")
+	}
+	path := filepath.Join(t.TempDir(), "books.ndjson")
+	if err := os.WriteFile(path, []byte(content.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := NewJSONProvider(path).GetBooks()
+	if err == nil {
+		t.Fatal("got no error")
+	}
+	lines := strings.Split(err.Error(), "\n")
+	if len(lines) != maxJSONProblems+1 || lines[maxJSONProblems] != fmt.Sprintf("  line %d: a highlight outside of a book needs a book_id", maxJSONProblems) {
+		t.Errorf("got %d problems ending with %q, want the first %d", len(lines)-1, lines[len(lines)-1], maxJSONProblems)
+	}
+}
+
+// TestJSONSchema checks that the schema describes the fields the provider accepts
+func TestJSONSchema(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join("..", "..", "schema", "bookmarks.schema.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema struct {
+		Defs map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(content, &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, value := range map[string]interface{}{"book": ReadwiseBook{}, "highlight": Highlight{}, "tag": Tag{}} {
+		var properties []string
+		for property := range schema.Defs[name].Properties {
+			properties = append(properties, property)
+		}
+		sort.Strings(properties)
+		if fields := jsonFields(reflect.TypeOf(value)); !reflect.DeepEqual(properties, fields) {
+			t.Errorf("got %s properties %v, want the fields %v", name, properties, fields)
+		}
+	}
+}
+
+// jsonFields lists the JSON names of the fields of a struct
+func jsonFields(structType reflect.Type) []string {
+	var fields []string
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
+	return fields
+}
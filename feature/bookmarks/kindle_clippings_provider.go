@@ -0,0 +1,342 @@
+package bookmarks
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// KindleClippingsProvider implements the BookmarksProvider interface by reading a Kindle "My Clippings.txt" file
+type KindleClippingsProvider struct {
+	path string
+	localLibrary
+}
+
+// NewKindleClippingsProvider creates a new KindleClippingsProvider for the given clippings file
+func NewKindleClippingsProvider(path string) *KindleClippingsProvider {
+	p := &KindleClippingsProvider{path: path}
+	p.load = p.parse
+	return p
+}
+
+// clippingSeparator ends every entry of the clippings file
+const clippingSeparator = "=========="
+
+type clippingKind int
+
+const (
+	clippingHighlight clippingKind = iota
+	clippingNote
+	clippingBookmark
+)
+
+// clipping is a single entry of the clippings file
+type clipping struct {
+	title         string
+	author        string
+	kind          clippingKind
+	page          int
+	startLocation int
+	endLocation   int
+	addedAt       time.Time
+	text          string
+}
+
+// Localized words of the metadata line, lowercased
+var (
+	kindleNoteWords     = []string{"note", "notiz", "nota", "notitie"}
+	kindleBookmarkWords = []string{"bookmark", "lesezeichen", "marcador", "signet", "segnalibro", "bladwijzer"}
+	kindleLocationWords = `location|loc\.|position|posición|posicion|emplacement|posizione|posição|posicao|locatie`
+	kindlePageWords     = `page|seite|página|pagina`
+)
+
+var (
+	kindleLocationPattern = regexp.MustCompile(`(?i)(?:` + kindleLocationWords + `)\s*(\d+)(?:\s*-\s*(\d+))?`)
+	kindlePagePattern     = regexp.MustCompile(`(?i)(?:` + kindlePageWords + `)\s*(\d+)`)
+	kindleAuthorPattern   = regexp.MustCompile(`^(.*)\s*\(([^()]*)\)\s*$`)
+)
+
+func (p *KindleClippingsProvider) parse() (*libraryBuilder, error) {
+	content, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read clippings file: %w", err)
+	}
+
+	clippings := parseClippings(string(content))
+	builder := newLibraryBuilder()
+
+	// Group the clippings by book, keeping the file order
+	byBook := make(map[int][]clipping)
+	for _, c := range clippings {
+		book := builder.addBook(ReadwiseBook{
+			ID:       syntheticID("kindle", normalizeKey(c.title), normalizeKey(c.author)),
+			Title:    c.title,
+			Author:   c.author,
+			Category: "books",
+			Source:   "kindle",
+		})
+		byBook[book.ID] = append(byBook[book.ID], c)
+	}
+
+	for bookID, bookClippings := range byBook {
+		for _, highlight := range kindleHighlights(bookID, bookClippings) {
+			builder.addHighlight(bookID, highlight)
+		}
+	}
+
+	return builder, nil
+}
+
+// parseClippings splits the file into entries and parses each one, skipping the malformed ones
+func parseClippings(content string) []clipping {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	content = strings.ReplaceAll(content, "\ufeff", "")
+
+	var clippings []clipping
+	for _, entry := range strings.Split(content, clippingSeparator) {
+		lines := strings.Split(strings.Trim(entry, "\n"), "\n")
+		if len(lines) < 2 {
+			continue
+		}
+
+		title, author := parseClippingTitle(strings.TrimSpace(lines[0]))
+		c := clipping{title: title, author: author}
+		parseClippingMeta(strings.TrimSpace(lines[1]), &c)
+		c.text = strings.TrimSpace(strings.Join(lines[2:], "\n"))
+		clippings = append(clippings, c)
+	}
+	return clippings
+}
+
+// parseClippingTitle splits "Title (Author)" into its parts
+func parseClippingTitle(line string) (string, string) {
+	if match := kindleAuthorPattern.FindStringSubmatch(line); match != nil {
+		return strings.TrimSpace(match[1]), strings.TrimSpace(match[2])
+	}
+	return line, ""
+}
+
+// parseClippingMeta parses a line like "- Your Highlight on page 12 | Location 180-182 | Added on Sunday, March 3, 2019 10:11:12 PM"
+func parseClippingMeta(line string, c *clipping) {
+	segments := strings.Split(line, "|")
+	kind := strings.ToLower(segments[0])
+	switch {
+	case containsAny(kind, kindleBookmarkWords):
+		c.kind = clippingBookmark
+	case containsAny(kind, kindleNoteWords) && !strings.Contains(kind, "highlight"):
+		c.kind = clippingNote
+	default:
+		c.kind = clippingHighlight
+	}
+
+	if match := kindlePagePattern.FindStringSubmatch(line); match != nil {
+		c.page, _ = strconv.Atoi(match[1])
+	}
+	if match := kindleLocationPattern.FindStringSubmatch(line); match != nil {
+		c.startLocation, _ = strconv.Atoi(match[1])
+		c.endLocation = c.startLocation
+		if match[2] != "" {
+			c.endLocation = expandLocationEnd(match[1], match[2])
+		}
+	}
+	if len(segments) > 1 {
+		c.addedAt = parseClippingDate(segments[len(segments)-1])
+	}
+}
+
+// expandLocationEnd handles the shortened ranges of older Kindles, like "1180-82"
+func expandLocationEnd(start, end string) int {
+	if len(end) < len(start) {
+		end = start[:len(start)-len(end)] + end
+	}
+	value, _ := strconv.Atoi(end)
+	return value
+}
+
+// kindleMonths maps the localized month names to their number
+var kindleMonths = map[string]time.Month{
+	"january": 1, "february": 2, "march": 3, "april": 4, "may": 5, "june": 6,
+	"july": 7, "august": 8, "september": 9, "october": 10, "november": 11, "december": 12,
+	"januar": 1, "februar": 2, "märz": 3, "mai": 5, "juni": 6, "juli": 7, "oktober": 10, "dezember": 12,
+	"enero": 1, "febrero": 2, "marzo": 3, "abril": 4, "mayo": 5, "junio": 6,
+	"julio": 7, "agosto": 8, "septiembre": 9, "setiembre": 9, "octubre": 10, "noviembre": 11, "diciembre": 12,
+	"janvier": 1, "février": 2, "mars": 3, "avril": 4, "juin": 6,
+	"juillet": 7, "août": 8, "septembre": 9, "octobre": 10, "novembre": 11, "décembre": 12,
+	"gennaio": 1, "febbraio": 2, "aprile": 4, "maggio": 5, "giugno": 6,
+	"luglio": 7, "settembre": 9, "ottobre": 10, "dicembre": 12,
+	"janeiro": 1, "fevereiro": 2, "março": 3, "maio": 5, "junho": 6,
+	"julho": 7, "setembro": 9, "outubro": 10, "novembro": 11, "dezembro": 12,
+}
+
+var (
+	clippingTimePattern    = regexp.MustCompile(`(\d{1,2}):(\d{2})(?::(\d{2}))?\s*([AaPp]\.?\s?[Mm]\.?)?`)
+	clippingNumericPattern = regexp.MustCompile(`(\d{4})[/.-](\d{1,2})[/.-](\d{1,2})|(\d{1,2})[/.](\d{1,2})[/.](\d{4})`)
+	clippingWordPattern    = regexp.MustCompile(`[\p{L}]+|\d+`)
+)
+
+// parseClippingDate parses the "Added on" part of the metadata in any of the supported locales.
+// The date is assumed to be in local time, like the Kindle clock.
+func parseClippingDate(segment string) time.Time {
+	var hour, minute, second int
+	if match := clippingTimePattern.FindStringSubmatch(segment); match != nil {
+		hour, _ = strconv.Atoi(match[1])
+		minute, _ = strconv.Atoi(match[2])
+		second, _ = strconv.Atoi(match[3])
+		meridiem := strings.ToLower(strings.NewReplacer(".", "", " ", "").Replace(match[4]))
+		if meridiem == "pm" && hour < 12 {
+			hour += 12
+		}
+		if meridiem == "am" && hour == 12 {
+			hour = 0
+		}
+		segment = strings.Replace(segment, match[0], " ", 1)
+	}
+
+	var year, day int
+	var month time.Month
+	if match := clippingNumericPattern.FindStringSubmatch(segment); match != nil {
+		if match[1] != "" {
+			year, _ = strconv.Atoi(match[1])
+			m, _ := strconv.Atoi(match[2])
+			month = time.Month(m)
+			day, _ = strconv.Atoi(match[3])
+		} else {
+			day, _ = strconv.Atoi(match[4])
+			m, _ := strconv.Atoi(match[5])
+			month = time.Month(m)
+			year, _ = strconv.Atoi(match[6])
+		}
+	} else {
+		for _, word := range clippingWordPattern.FindAllString(segment, -1) {
+			number, err := strconv.Atoi(word)
+			switch {
+			case err != nil:
+				if m, ok := kindleMonths[strings.ToLower(word)]; ok {
+					month = m
+				}
+			case len(word) == 4:
+				year = number
+			case number >= 1 && number <= 31 && day == 0:
+				day = number
+			}
+		}
+	}
+
+	if year == 0 || month == 0 || day == 0 {
+		return time.Time{}
+	}
+	return time.Date(year, month, day, hour, minute, second, 0, time.Local)
+}
+
+// kindleHighlights turns the clippings of a book into highlights: bookmarks are dropped,
+// re-highlighted passages are deduplicated and notes are attached to their highlight
+func kindleHighlights(bookID int, clippings []clipping) []Highlight {
+	var highlights []clipping
+	var notes []clipping
+	for _, c := range clippings {
+		switch c.kind {
+		case clippingHighlight:
+			if c.text != "" {
+				highlights = appendDeduplicated(highlights, c)
+			}
+		case clippingNote:
+			if c.text != "" {
+				notes = append(notes, c)
+			}
+		}
+	}
+
+	result := make([]Highlight, 0, len(highlights))
+	for _, c := range highlights {
+		result = append(result, clippingHighlightFrom(bookID, c))
+	}
+
+	for _, note := range notes {
+		if i := matchingHighlight(highlights, note); i >= 0 {
+			if result[i].Note != "" {
+				result[i].Note += "\n"
+			}
+			result[i].Note += note.text
+			continue
+		}
+		// A note without highlight is kept on its own
+		standalone := clippingHighlightFrom(bookID, note)
+		standalone.Text = ""
+		standalone.Note = note.text
+		result = append(result, standalone)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Location < result[j].Location
+	})
+	return result
+}
+
+// appendDeduplicated adds a highlight, replacing an earlier version of the same passage.
+// Extending a highlight on the Kindle adds a new clipping that overlaps the previous one.
+func appendDeduplicated(highlights []clipping, c clipping) []clipping {
+	for i, existing := range highlights {
+		overlaps := c.startLocation <= existing.endLocation && existing.startLocation <= c.endLocation
+		if !overlaps {
+			continue
+		}
+		if strings.Contains(c.text, existing.text) {
+			highlights[i] = c
+			return highlights
+		}
+		if strings.Contains(existing.text, c.text) {
+			return highlights
+		}
+	}
+	return append(highlights, c)
+}
+
+// matchingHighlight returns the index of the highlight a note belongs to, preferring the one ending at the note location
+func matchingHighlight(highlights []clipping, note clipping) int {
+	match := -1
+	for i, h := range highlights {
+		if h.endLocation == note.startLocation {
+			return i
+		}
+		if match < 0 && h.startLocation <= note.startLocation && note.startLocation <= h.endLocation {
+			match = i
+		}
+	}
+	return match
+}
+
+func clippingHighlightFrom(bookID int, c clipping) Highlight {
+	kind := "highlight"
+	if c.kind == clippingNote {
+		kind = "note"
+	}
+
+	highlight := Highlight{
+		ID:            syntheticID("kindle", strconv.Itoa(bookID), kind, strconv.Itoa(c.startLocation), c.text),
+		Text:          c.text,
+		Location:      c.startLocation,
+		EndLocation:   c.endLocation,
+		LocationType:  "location",
+		HighlightedAt: c.addedAt,
+		Updated:       c.addedAt,
+	}
+	if c.startLocation == 0 && c.page > 0 {
+		highlight.Location = c.page
+		highlight.EndLocation = c.page
+		highlight.LocationType = "page"
+	}
+	return highlight
+}
+
+func containsAny(value string, words []string) bool {
+	for _, word := range words {
+		if strings.Contains(value, word) {
+			return true
+		}
+	}
+	return false
+}
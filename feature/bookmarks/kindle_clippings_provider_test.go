@@ -0,0 +1,79 @@
+package bookmarks
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestKindleClippingsProvider(t *testing.T) {
+	tests := []struct {
+		title     string
+		author    string
+		highlight Highlight
+	}{
+		{
+			// Extended highlight replaces the first one, the note is attached, the bookmark dropped
+			title:     "Deep Work",
+			author:    "Cal Newport",
+			highlight: Highlight{Text: "Focus is rare and valuable", Note: "Quote this", Location: 180, EndLocation: 185, LocationType: "location", HighlightedAt: time.Date(2019, 3, 3, 22, 12, 0, 0, time.Local)},
+		},
+		{
+			// German metadata with a shortened location range
+			title:     "Der Process",
+			author:    "Kafka, Franz",
+			highlight: Highlight{Text: "Jemand mußte Josef K. verleumdet haben", Location: 1180, EndLocation: 1182, LocationType: "location", HighlightedAt: time.Date(2019, 3, 4, 8, 0, 0, 0, time.Local)},
+		},
+		{
+			// French metadata with a page only
+			title:     "Le Petit Prince",
+			author:    "Antoine de Saint-Exupéry",
+			highlight: Highlight{Text: "L'essentiel est invisible pour les yeux", Location: 7, EndLocation: 7, LocationType: "page", HighlightedAt: time.Date(2019, 3, 4, 9, 30, 0, 0, time.Local)},
+		},
+	}
+
+	provider := NewKindleClippingsProvider(filepath.Join("testdata", "kindle", "My Clippings.txt"))
+	books, err := provider.GetBooks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(books) != len(tests) {
+		t.Fatalf("got %d books, want %d", len(books), len(tests))
+	}
+	for i, test := range tests {
+		t.Run(test.title, func(t *testing.T) {
+			book := books[i]
+			if book.Title != test.title || book.Author != test.author || book.Source != "kindle" {
+				t.Errorf("got book %q by %q from %s, want %q by %q", book.Title, book.Author, book.Source, test.title, test.author)
+			}
+			highlights, err := provider.GetHighlights(book.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(highlights) != 1 {
+				t.Fatalf("got %d highlights, want 1", len(highlights))
+			}
+			got, want := highlights[0], test.highlight
+			if got.Text != want.Text || got.Note != want.Note || got.Location != want.Location || got.EndLocation != want.EndLocation ||
+				got.LocationType != want.LocationType || !got.HighlightedAt.Equal(want.HighlightedAt) {
+				t.Errorf("got highlight %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func FuzzParseClippings(f *testing.F) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "kindle", "My Clippings.txt"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(string(sample))
+	f.Add("Title\n- Your Highlight | Location 5-\n==========")
+	f.Add("(\n- Note on Location 99999999999999999999-1 | Added on 31/02/0000 99:99 PM\n\n==========")
+
+	f.Fuzz(func(t *testing.T, content string) {
+		clippings := parseClippings(content)
+		kindleHighlights(1, clippings)
+	})
+}
@@ -0,0 +1,106 @@
+package bookmarks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKoboProvider(t *testing.T) {
+	tests := []struct {
+		title      string
+		author     string
+		highlights []Highlight
+	}{
+		{
+			// Sorted by chapter then position, the dogears are dropped and the hidden highlight is deleted
+			title:  "Deep Work",
+			author: "Cal Newport",
+			highlights: []Highlight{
+				{Text: "First chapter highlight", Note: "A note", Chapter: "Chapter One", Color: "yellow", Location: 1,
+					HighlightedAt: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), Updated: time.Date(2024, 1, 5, 8, 0, 0, 0, time.UTC)},
+				{Text: "Hidden highlight", Chapter: "Chapter One", Location: 2, IsDeleted: true,
+					HighlightedAt: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC), Updated: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)},
+				{Text: "Second chapter highlight", Chapter: "Chapter Two", Color: "blue", Location: 3,
+					HighlightedAt: time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC), Updated: time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			// Sideloaded book without content row, the highlight is stored on overflow pages
+			title: "sideloaded",
+			highlights: []Highlight{
+				{Text: strings.TrimSpace(strings.Repeat("Long ", 600)), Location: 1,
+					HighlightedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Updated: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+	}
+
+	provider := NewKoboProvider(filepath.Join("testdata", "kobo", "KoboReader.sqlite"))
+	books, err := provider.GetBooks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(books) != len(tests) {
+		t.Fatalf("got %d books, want %d", len(books), len(tests))
+	}
+	for i, test := range tests {
+		t.Run(test.title, func(t *testing.T) {
+			book := books[i]
+			if book.Title != test.title || book.Author != test.author {
+				t.Errorf("got book %q by %q, want %q by %q", book.Title, book.Author, test.title, test.author)
+			}
+			highlights, err := provider.GetHighlights(book.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(highlights) != len(test.highlights) {
+				t.Fatalf("got %d highlights, want %d", len(highlights), len(test.highlights))
+			}
+			for j, want := range test.highlights {
+				got := highlights[j]
+				if got.Text != want.Text || got.Note != want.Note || got.Chapter != want.Chapter || got.Color != want.Color ||
+					got.Location != want.Location || got.IsDeleted != want.IsDeleted ||
+					!got.HighlightedAt.Equal(want.HighlightedAt) || !got.Updated.Equal(want.Updated) {
+					t.Errorf("got highlight %+v, want %+v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSQLiteLeafCellTruncated(t *testing.T) {
+	db := &sqliteDatabase{data: make([]byte, 1024), pageSize: 1024, usable: 1024}
+	tests := []struct {
+		name    string
+		content []byte
+	}{
+		{name: "payload size", content: []byte{0x81, 0x82}},
+		{name: "rowid", content: []byte{0x05, 0xff}},
+		{name: "payload size larger than the file", content: []byte{0x84, 0x80, 0x80, 0x00, 0x01}},
+	}
+	for _, test := range tests {
+		if _, _, err := db.leafCell(test.content, 0); err == nil {
+			t.Errorf("%s: got no error for a truncated cell", test.name)
+		}
+	}
+}
+
+func FuzzSQLiteDatabase(f *testing.F) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "kobo", "KoboReader.sqlite"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(sample)
+	f.Add(sample[:1024])
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		db, err := newSQLiteDatabase(data)
+		if err != nil {
+			return
+		}
+		db.readTable("content")
+		db.readTable("Bookmark")
+	})
+}
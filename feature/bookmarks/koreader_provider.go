@@ -0,0 +1,224 @@
+package bookmarks
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// KOReaderProvider implements the BookmarksProvider interface by reading the
+// metadata.*.lua sidecar files KOReader keeps in the .sdr directory next to each book
+type KOReaderProvider struct {
+	path string
+	localLibrary
+}
+
+// NewKOReaderProvider creates a new KOReaderProvider for the given directory, .sdr directory or metadata file
+func NewKOReaderProvider(path string) *KOReaderProvider {
+	p := &KOReaderProvider{path: path}
+	p.load = p.parse
+	return p
+}
+
+// koreaderDateLayout is the format of the datetime fields of the sidecar files, in the device local time
+const koreaderDateLayout = "2006-01-02 15:04:05"
+
+var (
+	koreaderMetadataPattern = regexp.MustCompile(`^metadata\.[^.]+\.lua$`)
+	// koreaderLegacyNotePattern matches the text KOReader used to generate for bookmarks without a note
+	koreaderLegacyNotePattern = regexp.MustCompile(`^Page \d+ .* @ \d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}$`)
+)
+
+func (p *KOReaderProvider) parse() (*libraryBuilder, error) {
+	files, err := koreaderMetadataFiles(p.path)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := newLibraryBuilder()
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read KOReader metadata %s: %w", file, err)
+		}
+		metadata, err := parseLuaTable(string(content))
+		if err != nil {
+			fmt.Printf("Warning: skipping KOReader metadata %s: %v\n", file, err)
+			continue
+		}
+
+		book, key := koreaderBook(file, metadata)
+		added := builder.addBook(book)
+		for _, highlight := range koreaderHighlights(key, metadata) {
+			builder.addHighlight(added.ID, highlight)
+		}
+	}
+	return builder, nil
+}
+
+// koreaderMetadataFiles finds the sidecar files under the given path
+func koreaderMetadataFiles(root string) ([]string, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read KOReader directory: %w", err)
+	}
+	if !info.IsDir() {
+		return []string{root}, nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || !koreaderMetadataPattern.MatchString(entry.Name()) {
+			return nil
+		}
+		if strings.HasSuffix(filepath.Dir(path), ".sdr") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan KOReader directory: %w", err)
+	}
+	return files, nil
+}
+
+// koreaderBook reads the book metadata, and returns the book with the key its IDs derive from
+func koreaderBook(file string, metadata luaTable) (ReadwiseBook, string) {
+	props := metadata.Table("doc_props")
+	stats := metadata.Table("stats")
+
+	title := firstNonEmpty(props.String("title"), stats.String("title"))
+	if title == "" {
+		// Fall back to the document name: "Book.epub" for "Book.sdr/metadata.epub.lua"
+		docPath := metadata.String("doc_path")
+		if docPath == "" {
+			docPath = strings.TrimSuffix(filepath.Dir(file), ".sdr")
+		}
+		title = strings.TrimSuffix(filepath.Base(docPath), filepath.Ext(docPath))
+	}
+
+	// Multiple authors are separated by new lines
+	var authors []string
+	for _, author := range strings.Split(firstNonEmpty(props.String("authors"), stats.String("authors")), "\n") {
+		if author = strings.TrimSpace(author); author != "" {
+			authors = append(authors, author)
+		}
+	}
+	author := strings.Join(authors, "; ")
+
+	// The checksum identifies the document even when it is renamed or its metadata is edited
+	key := metadata.String("partial_md5_checksum")
+	if key == "" {
+		key = normalizeKey(title) + "\x00" + normalizeKey(author)
+	}
+
+	return ReadwiseBook{
+		ID:       syntheticID("koreader", key),
+		Title:    title,
+		Author:   author,
+		Category: "books",
+		Source:   "koreader",
+		Language: firstNonEmpty(props.String("language"), stats.String("language")),
+	}, key
+}
+
+// koreaderHighlights reads the annotations table, or the highlight and bookmarks tables of older KOReader versions
+func koreaderHighlights(key string, metadata luaTable) []Highlight {
+	if annotations := metadata.Table("annotations"); annotations != nil {
+		var highlights []Highlight
+		for _, annotation := range annotations.List() {
+			// Page bookmarks have no highlighted position
+			if annotation["pos0"] == nil && annotation.String("drawer") == "" {
+				continue
+			}
+			highlights = append(highlights, koreaderHighlight(key, annotation, annotation.String("note")))
+		}
+		return highlights
+	}
+
+	// Older versions keep the notes in the bookmarks, matched to the highlights by their date
+	notes := make(map[string]string)
+	for _, bookmark := range metadata.Table("bookmarks").List() {
+		text := strings.TrimSpace(bookmark.String("text"))
+		if bookmark["highlighted"] != true || text == "" || text == strings.TrimSpace(bookmark.String("notes")) || koreaderLegacyNotePattern.MatchString(text) {
+			continue
+		}
+		notes[bookmark.String("datetime")] = text
+	}
+
+	var highlights []Highlight
+	pages := metadata.Table("highlight")
+	for _, page := range pages.Indexes() {
+		for _, entry := range pages.Table(strconv.Itoa(page)).List() {
+			// The highlights are grouped by page number
+			if entry.Int("pageno") == 0 {
+				entry["pageno"] = float64(page)
+			}
+			highlights = append(highlights, koreaderHighlight(key, entry, notes[entry.String("datetime")]))
+		}
+	}
+	return highlights
+}
+
+func koreaderHighlight(key string, annotation luaTable, note string) Highlight {
+	text := strings.TrimSpace(annotation.String("text"))
+	created := parseKOReaderDate(annotation.String("datetime"))
+	updated := parseKOReaderDate(annotation.String("datetime_updated"))
+	if updated.IsZero() {
+		updated = created
+	}
+
+	// Reflowable documents store an xpointer in page and the page number in pageno
+	page := annotation.Int("pageno")
+	if page == 0 {
+		page = annotation.Int("page")
+	}
+
+	// The color only exists since KOReader 2024.04, before that the drawer style is the closest thing
+	color := annotation.String("color")
+	if color == "" {
+		color = annotation.String("drawer")
+	}
+
+	highlight := Highlight{
+		ID:            syntheticID("koreader", key, annotation.String("datetime"), normalizeKey(text)),
+		Text:          text,
+		Note:          strings.TrimSpace(note),
+		Chapter:       annotation.String("chapter"),
+		Color:         color,
+		HighlightedAt: created,
+		CreatedAt:     created,
+		Updated:       updated,
+	}
+	if page > 0 {
+		highlight.Location = page
+		highlight.EndLocation = page
+		highlight.LocationType = "page"
+	}
+	return highlight
+}
+
+func parseKOReaderDate(value string) time.Time {
+	date, err := time.ParseInLocation(koreaderDateLayout, value, time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	return date
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
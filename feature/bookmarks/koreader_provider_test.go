@@ -0,0 +1,123 @@
+package bookmarks
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestKOReaderProvider(t *testing.T) {
+	date := func(value string) time.Time {
+		return parseKOReaderDate(value)
+	}
+	tests := []struct {
+		title      string
+		author     string
+		highlights []Highlight
+	}{
+		{
+			// Annotations table, the page bookmark without position is dropped
+			title:  "Deep Work",
+			author: "Cal Newport; Other Author",
+			highlights: []Highlight{{
+				Text: "Focus is rare and \"valuable\"\nin the new economy", Note: "Quote this", Chapter: "Part 1", Color: "yellow",
+				Location: 12, LocationType: "page", HighlightedAt: date("2024-05-01 10:00:00"), Updated: date("2024-05-02 11:30:00"),
+			}},
+		},
+		{
+			// Highlight and bookmarks tables of older versions, the title comes from the document path
+			title:  "Old Book",
+			author: "Jane Doe",
+			highlights: []Highlight{
+				{Text: "Old highlighted text", Note: "An old note", Color: "underscore", Location: 5, LocationType: "page", HighlightedAt: date("2020-01-02 03:04:05"), Updated: date("2020-01-02 03:04:05")},
+				{Text: "Without note", Color: "lighten", Location: 8, LocationType: "page", HighlightedAt: date("2020-01-03 00:00:00"), Updated: date("2020-01-03 00:00:00")},
+			},
+		},
+	}
+
+	provider := NewKOReaderProvider(filepath.Join("testdata", "koreader"))
+	books, err := provider.GetBooks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(books) != len(tests) {
+		t.Fatalf("got %d books, want %d", len(books), len(tests))
+	}
+	for i, test := range tests {
+		t.Run(test.title, func(t *testing.T) {
+			book := books[i]
+			if book.Title != test.title || book.Author != test.author {
+				t.Errorf("got book %q by %q, want %q by %q", book.Title, book.Author, test.title, test.author)
+			}
+			highlights, err := provider.GetHighlights(book.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(highlights) != len(test.highlights) {
+				t.Fatalf("got %d highlights, want %d", len(highlights), len(test.highlights))
+			}
+			for j, want := range test.highlights {
+				got := highlights[j]
+				if got.Text != want.Text || got.Note != want.Note || got.Chapter != want.Chapter || got.Color != want.Color ||
+					got.Location != want.Location || got.LocationType != want.LocationType ||
+					!got.HighlightedAt.Equal(want.HighlightedAt) || !got.Updated.Equal(want.Updated) {
+					t.Errorf("got highlight %+v, want %+v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseLuaTable(t *testing.T) {
+	tests := []struct {
+		source string
+		want   luaTable
+		err    bool
+	}{
+		{source: `return { a = 1, ["b"] = "x", [3] = true, }`, want: luaTable{"a": 1.0, "b": "x", "3": true}},
+		{source: `return { "first"; 'second', [[long]] }`, want: luaTable{"1": "first", "2": "second", "3": "long"}},
+		{source: "-- comment\nreturn { --[[ block ]] s = \"tab\\tnew\\nline\\65\\x42\" }", want: luaTable{"s": "tab\tnew\nlineAB"}},
+		{source: `return { n = -1.5e2, h = 0x10, nested = { x = nil } }`, want: luaTable{"n": -150.0, "h": 16.0, "nested": luaTable{"x": nil}}},
+		{source: `return { a = "unterminated }`, err: true},
+		{source: `return { a = 1`, err: true},
+		{source: `return { a = @ }`, err: true},
+	}
+	for _, test := range tests {
+		got, err := parseLuaTable(test.source)
+		if test.err {
+			if err == nil {
+				t.Errorf("parseLuaTable(%q) = %v, want an error", test.source, got)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, test.want) {
+			t.Errorf("parseLuaTable(%q) = %v, %v, want %v", test.source, got, err, test.want)
+		}
+	}
+}
+
+func FuzzParseLuaTable(f *testing.F) {
+	files, err := filepath.Glob(filepath.Join("testdata", "koreader", "*.sdr", "*.lua"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(string(content))
+	}
+	f.Add(`return { [{}] = { "\x", '\999' }, [[`)
+
+	f.Fuzz(func(t *testing.T, source string) {
+		metadata, err := parseLuaTable(source)
+		if err != nil {
+			return
+		}
+		_, key := koreaderBook("metadata.epub.lua", metadata)
+		koreaderHighlights(key, metadata)
+	})
+}
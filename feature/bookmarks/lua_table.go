@@ -0,0 +1,344 @@
+package bookmarks
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// luaTable is a Lua table literal, as written by KOReader in its metadata files.
+// Every key is stored as a string: numeric keys are formatted with strconv.
+type luaTable map[string]interface{}
+
+// parseLuaTable parses a "return { ... }" Lua file made of table literals only
+func parseLuaTable(source string) (luaTable, error) {
+	p := &luaParser{src: source}
+	p.skipSpace()
+	if strings.HasPrefix(p.src[p.pos:], "return") {
+		p.pos += len("return")
+	}
+
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	table, ok := value.(luaTable)
+	if !ok {
+		return nil, fmt.Errorf("expected a table at the top level")
+	}
+	return table, nil
+}
+
+// String returns the string value of a key, or "" if missing
+func (t luaTable) String(key string) string {
+	if value, ok := t[key].(string); ok {
+		return value
+	}
+	return ""
+}
+
+// Int returns the numeric value of a key, or 0 if missing
+func (t luaTable) Int(key string) int {
+	if value, ok := t[key].(float64); ok {
+		return int(value)
+	}
+	return 0
+}
+
+// Table returns the table value of a key, or nil if missing
+func (t luaTable) Table(key string) luaTable {
+	if value, ok := t[key].(luaTable); ok {
+		return value
+	}
+	return nil
+}
+
+// Indexes returns the numeric keys of the table in increasing order
+func (t luaTable) Indexes() []int {
+	var indexes []int
+	for key := range t {
+		if index, err := strconv.Atoi(key); err == nil {
+			indexes = append(indexes, index)
+		}
+	}
+	sort.Ints(indexes)
+	return indexes
+}
+
+// List returns the tables stored under numeric keys, in key order
+func (t luaTable) List() []luaTable {
+	var items []luaTable
+	for _, key := range t.Indexes() {
+		if item, ok := t[strconv.Itoa(key)].(luaTable); ok {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+type luaParser struct {
+	src string
+	pos int
+}
+
+func (p *luaParser) errorf(format string, args ...interface{}) error {
+	line := strings.Count(p.src[:p.pos], "\n") + 1
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// skipSpace skips whitespace and comments
+func (p *luaParser) skipSpace() {
+	for p.pos < len(p.src) {
+		switch {
+		case unicode.IsSpace(rune(p.src[p.pos])):
+			p.pos++
+		case strings.HasPrefix(p.src[p.pos:], "--[["):
+			end := strings.Index(p.src[p.pos:], "]]")
+			if end < 0 {
+				p.pos = len(p.src)
+			} else {
+				p.pos += end + 2
+			}
+		case strings.HasPrefix(p.src[p.pos:], "--"):
+			end := strings.IndexByte(p.src[p.pos:], '\n')
+			if end < 0 {
+				p.pos = len(p.src)
+			} else {
+				p.pos += end + 1
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *luaParser) parseValue() (interface{}, error) {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return nil, p.errorf("unexpected end of file")
+	}
+
+	c := p.src[p.pos]
+	switch {
+	case c == '{':
+		return p.parseTable()
+	case c == '"' || c == '\'':
+		return p.parseString()
+	case c == '[' && strings.HasPrefix(p.src[p.pos:], "[["):
+		return p.parseLongString()
+	case c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		return p.parseNumber()
+	}
+
+	word := p.parseName()
+	switch word {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "nil":
+		return nil, nil
+	}
+	return nil, p.errorf("unexpected %q", word)
+}
+
+func (p *luaParser) parseTable() (luaTable, error) {
+	p.pos++ // {
+	table := make(luaTable)
+	nextIndex := 1
+
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			return nil, p.errorf("unterminated table")
+		}
+		if p.src[p.pos] == '}' {
+			p.pos++
+			return table, nil
+		}
+
+		var key string
+		switch {
+		case p.src[p.pos] == '[' && !strings.HasPrefix(p.src[p.pos:], "[["):
+			p.pos++
+			keyValue, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			key = luaKey(keyValue)
+			p.skipSpace()
+			if !p.consume(']') {
+				return nil, p.errorf("expected ]")
+			}
+			p.skipSpace()
+			if !p.consume('=') {
+				return nil, p.errorf("expected =")
+			}
+		case isNameStart(p.src[p.pos]) && p.isNamedField():
+			key = p.parseName()
+			p.skipSpace()
+			p.consume('=')
+		default:
+			key = strconv.Itoa(nextIndex)
+			nextIndex++
+		}
+
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		table[key] = value
+
+		p.skipSpace()
+		if !p.consume(',') && !p.consume(';') {
+			p.skipSpace()
+			if p.pos < len(p.src) && p.src[p.pos] != '}' {
+				return nil, p.errorf("expected , or }")
+			}
+		}
+	}
+}
+
+// isNamedField reports whether the name at the current position is followed by "="
+func (p *luaParser) isNamedField() bool {
+	start := p.pos
+	defer func() { p.pos = start }()
+	p.parseName()
+	p.skipSpace()
+	return p.pos < len(p.src) && p.src[p.pos] == '=' && !strings.HasPrefix(p.src[p.pos:], "==")
+}
+
+func (p *luaParser) consume(c byte) bool {
+	if p.pos < len(p.src) && p.src[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *luaParser) parseName() string {
+	start := p.pos
+	for p.pos < len(p.src) && (isNameStart(p.src[p.pos]) || (p.src[p.pos] >= '0' && p.src[p.pos] <= '9')) {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func (p *luaParser) parseNumber() (float64, error) {
+	start := p.pos
+	for p.pos < len(p.src) && strings.IndexByte("0123456789+-.eExXabcdefABCDEF", p.src[p.pos]) >= 0 {
+		p.pos++
+	}
+	text := p.src[start:p.pos]
+	if strings.HasPrefix(text, "0x") || strings.HasPrefix(text, "-0x") {
+		value, err := strconv.ParseInt(text, 0, 64)
+		return float64(value), err
+	}
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, p.errorf("invalid number %q", text)
+	}
+	return value, nil
+}
+
+func (p *luaParser) parseString() (string, error) {
+	quote := p.src[p.pos]
+	p.pos++
+
+	var b strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return b.String(), nil
+		case c == '\\' && p.pos+1 < len(p.src):
+			p.pos++
+			p.parseEscape(&b)
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+// parseEscape decodes the escape sequence after a backslash
+func (p *luaParser) parseEscape(b *strings.Builder) {
+	c := p.src[p.pos]
+	p.pos++
+	switch c {
+	case 'n':
+		b.WriteByte('\n')
+	case 't':
+		b.WriteByte('\t')
+	case 'r':
+		b.WriteByte('\r')
+	case 'a':
+		b.WriteByte('\a')
+	case 'b':
+		b.WriteByte('\b')
+	case 'f':
+		b.WriteByte('\f')
+	case 'v':
+		b.WriteByte('\v')
+	case '\n':
+		b.WriteByte('\n')
+	case 'z':
+		for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+			p.pos++
+		}
+	case 'x':
+		if p.pos+2 <= len(p.src) {
+			if value, err := strconv.ParseUint(p.src[p.pos:p.pos+2], 16, 8); err == nil {
+				b.WriteByte(byte(value))
+				p.pos += 2
+			}
+		}
+	default:
+		if c >= '0' && c <= '9' {
+			digits := string(c)
+			for len(digits) < 3 && p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+				digits += string(p.src[p.pos])
+				p.pos++
+			}
+			value, _ := strconv.Atoi(digits)
+			b.WriteByte(byte(value))
+			return
+		}
+		b.WriteByte(c)
+	}
+}
+
+func (p *luaParser) parseLongString() (string, error) {
+	p.pos += 2
+	end := strings.Index(p.src[p.pos:], "]]")
+	if end < 0 {
+		return "", p.errorf("unterminated long string")
+	}
+	value := strings.TrimPrefix(p.src[p.pos:p.pos+end], "\n")
+	p.pos += end + 2
+	return value, nil
+}
+
+// luaKey formats a table key as a string
+func luaKey(value interface{}) string {
+	switch v := value.(type) {
+	case float64:
+		if v == float64(int64(v)) {
+			return strconv.FormatInt(int64(v), 10)
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,271 @@
+package bookmarks
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Mirror is a local archive of every book and highlight fetched from the sources, with their
+// edits and deletions. Changes are appended as NDJSON events to a new segment file on every run,
+// and the current library is rebuilt by replaying the segments in order.
+type Mirror struct {
+	dir     string
+	segment string
+	loaded  bool
+	books   map[int]*mirroredBook
+	order   []int
+}
+
+// mirroredBook is the last known version of a book and its highlights
+type mirroredBook struct {
+	source     string
+	book       ReadwiseBook
+	deleted    bool
+	highlights map[int]Highlight
+	// removed holds the highlights deleted from the source
+	removed map[int]bool
+	order   []int
+}
+
+// MirrorEvent is a line of a segment
+type MirrorEvent struct {
+	At time.Time `json:"at"`
+	// Op is one of book, highlight, delete_book or delete_highlight
+	Op          string        `json:"op"`
+	Source      string        `json:"source,omitempty"`
+	BookID      int           `json:"book_id"`
+	HighlightID int           `json:"highlight_id,omitempty"`
+	Book        *ReadwiseBook `json:"book,omitempty"`
+	Highlight   *Highlight    `json:"highlight,omitempty"`
+}
+
+// Mirror event operations
+const (
+	MirrorOpBook            = "book"
+	MirrorOpHighlight       = "highlight"
+	MirrorOpDeleteBook      = "delete_book"
+	MirrorOpDeleteHighlight = "delete_highlight"
+)
+
+// NewMirror creates a new Mirror stored in the given directory
+func NewMirror(dir string) *Mirror {
+	return &Mirror{dir: dir}
+}
+
+// load replays the segments of the mirror
+func (m *Mirror) load() error {
+	if m.loaded {
+		return nil
+	}
+	m.books = make(map[int]*mirroredBook)
+	m.order = nil
+
+	segments, err := filepath.Glob(filepath.Join(m.dir, "*.ndjson"))
+	if err != nil {
+		return fmt.Errorf("failed to list mirror segments: %w", err)
+	}
+	sort.Strings(segments)
+	for _, segment := range segments {
+		if err := m.replay(segment); err != nil {
+			return err
+		}
+	}
+	m.loaded = true
+	return nil
+}
+
+func (m *Mirror) replay(segment string) error {
+	file, err := os.Open(segment)
+	if err != nil {
+		return fmt.Errorf("failed to read mirror segment: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var event MirrorEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return fmt.Errorf("failed to decode mirror segment %s line %d: %w", filepath.Base(segment), line, err)
+		}
+		m.apply(event)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read mirror segment %s: %w", filepath.Base(segment), err)
+	}
+	return nil
+}
+
+// apply updates the library with an event
+func (m *Mirror) apply(event MirrorEvent) {
+	book := m.books[event.BookID]
+	if book == nil {
+		if event.Op != MirrorOpBook || event.Book == nil {
+			return
+		}
+		book = &mirroredBook{highlights: make(map[int]Highlight), removed: make(map[int]bool)}
+		m.books[event.BookID] = book
+		m.order = append(m.order, event.BookID)
+	}
+
+	switch event.Op {
+	case MirrorOpBook:
+		if event.Book != nil {
+			book.book = *event.Book
+			book.source = event.Source
+			book.deleted = false
+		}
+	case MirrorOpDeleteBook:
+		book.deleted = true
+	case MirrorOpHighlight:
+		if event.Highlight != nil {
+			if _, ok := book.highlights[event.Highlight.ID]; !ok {
+				book.order = append(book.order, event.Highlight.ID)
+			}
+			book.highlights[event.Highlight.ID] = *event.Highlight
+			delete(book.removed, event.Highlight.ID)
+		}
+	case MirrorOpDeleteHighlight:
+		book.removed[event.HighlightID] = true
+	}
+}
+
+// write appends events to the segment of this run, and applies them
+func (m *Mirror) write(events []MirrorEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	if m.segment == "" {
+		if err := os.MkdirAll(m.dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create mirror directory: %w", err)
+		}
+		// Segments sort by name in the order they were written
+		m.segment = filepath.Join(m.dir, time.Now().UTC().Format("20060102T150405.000000000Z")+".ndjson")
+	}
+
+	var buf bytes.Buffer
+	for _, event := range events {
+		line, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to encode mirror event: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+		m.apply(event)
+	}
+
+	file, err := os.OpenFile(m.segment, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open mirror segment: %w", err)
+	}
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write mirror segment: %w", err)
+	}
+	return file.Close()
+}
+
+// RecordBooks records the books returned by a source, and the deletion of the books of the
+// same source that it no longer returns
+func (m *Mirror) RecordBooks(source string, books []ReadwiseBook) error {
+	if err := m.load(); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	current := make(map[int]bool, len(books))
+	var events []MirrorEvent
+	for i := range books {
+		book := books[i]
+		current[book.ID] = true
+		existing := m.books[book.ID]
+		if existing != nil && !existing.deleted && existing.source == source && sameJSON(existing.book, book) {
+			continue
+		}
+		events = append(events, MirrorEvent{At: now, Op: MirrorOpBook, Source: source, BookID: book.ID, Book: &book})
+	}
+
+	for _, id := range m.order {
+		book := m.books[id]
+		if !current[id] && !book.deleted && book.source == source {
+			events = append(events, MirrorEvent{At: now, Op: MirrorOpDeleteBook, Source: source, BookID: id})
+		}
+	}
+	return m.write(events)
+}
+
+// RecordHighlights records the highlights of a book, and the deletion of the ones it no longer has
+func (m *Mirror) RecordHighlights(source string, bookID int, highlights []Highlight) error {
+	if err := m.load(); err != nil {
+		return err
+	}
+	book := m.books[bookID]
+	if book == nil {
+		return fmt.Errorf("book %d is not mirrored", bookID)
+	}
+
+	now := time.Now().UTC()
+	current := make(map[int]bool, len(highlights))
+	var events []MirrorEvent
+	for i := range highlights {
+		highlight := highlights[i]
+		if highlight.IsDeleted {
+			continue
+		}
+		current[highlight.ID] = true
+		existing, ok := book.highlights[highlight.ID]
+		if ok && !book.removed[highlight.ID] && sameJSON(existing, highlight) {
+			continue
+		}
+		events = append(events, MirrorEvent{At: now, Op: MirrorOpHighlight, Source: source, BookID: bookID, HighlightID: highlight.ID, Highlight: &highlight})
+	}
+
+	for _, id := range book.order {
+		if !current[id] && !book.removed[id] {
+			events = append(events, MirrorEvent{At: now, Op: MirrorOpDeleteHighlight, Source: source, BookID: bookID, HighlightID: id})
+		}
+	}
+	return m.write(events)
+}
+
+// library returns the books and highlights of the mirror, without the deleted ones
+func (m *Mirror) library() (*libraryBuilder, error) {
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+
+	builder := newLibraryBuilder()
+	for _, id := range m.order {
+		book := m.books[id]
+		if book.deleted {
+			continue
+		}
+		added := builder.addBook(book.book)
+		for _, highlightID := range book.order {
+			if !book.removed[highlightID] {
+				builder.addHighlight(added.ID, book.highlights[highlightID])
+			}
+		}
+	}
+	return builder, nil
+}
+
+func sameJSON(a, b interface{}) bool {
+	first, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	second, err := json.Marshal(b)
+	return err == nil && bytes.Equal(first, second)
+}
@@ -0,0 +1,55 @@
+package bookmarks
+
+import (
+	"fmt"
+)
+
+// MirroredProvider implements the BookmarksProvider interface by recording everything
+// another provider returns into a Mirror
+type MirroredProvider struct {
+	provider BookmarksProvider
+	mirror   *Mirror
+	source   string
+}
+
+// NewMirroredProvider creates a new MirroredProvider recording the given source into the mirror
+func NewMirroredProvider(provider BookmarksProvider, mirror *Mirror, source string) *MirroredProvider {
+	return &MirroredProvider{provider: provider, mirror: mirror, source: source}
+}
+
+// GetBooks returns the books of the provider, after recording them
+func (p *MirroredProvider) GetBooks() ([]ReadwiseBook, error) {
+	books, err := p.provider.GetBooks()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.mirror.RecordBooks(p.source, books); err != nil {
+		return nil, fmt.Errorf("failed to mirror books: %w", err)
+	}
+	return books, nil
+}
+
+// GetHighlights returns the highlights of the provider, after recording them
+func (p *MirroredProvider) GetHighlights(bookID int) ([]Highlight, error) {
+	highlights, err := p.provider.GetHighlights(bookID)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.mirror.RecordHighlights(p.source, bookID, highlights); err != nil {
+		return nil, fmt.Errorf("failed to mirror highlights: %w", err)
+	}
+	return highlights, nil
+}
+
+// MirrorProvider implements the BookmarksProvider interface by reading a Mirror, for offline runs
+type MirrorProvider struct {
+	mirror *Mirror
+	localLibrary
+}
+
+// NewMirrorProvider creates a new MirrorProvider for the given mirror directory
+func NewMirrorProvider(dir string) *MirrorProvider {
+	p := &MirrorProvider{mirror: NewMirror(dir)}
+	p.load = p.mirror.library
+	return p
+}
@@ -0,0 +1,138 @@
+package bookmarks
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMirror(t *testing.T) {
+	dir := t.TempDir()
+	deepWork := ReadwiseBook{ID: 1, Title: "Deep Work"}
+	walden := ReadwiseBook{ID: 2, Title: "Walden"}
+	focus := Highlight{ID: 10, BookID: 1, Text: "Focus is rare"}
+	simplify := Highlight{ID: 20, BookID: 2, Text: "Simplify, simplify"}
+	woods := Highlight{ID: 21, BookID: 2, Text: "I went to the woods"}
+
+	// sync reads every book and highlight of a source through a new mirror, as a run does
+	sync := func(source string, provider staticProvider) {
+		t.Helper()
+		mirrored := NewMirroredProvider(provider, NewMirror(dir), source)
+		books, err := mirrored.GetBooks()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, book := range books {
+			if _, err := mirrored.GetHighlights(book.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	sync("kindle", staticProvider{
+		books:      []ReadwiseBook{deepWork, walden},
+		highlights: map[int][]Highlight{1: {focus}, 2: {simplify, woods}},
+	})
+	sync("kobo", staticProvider{
+		books:      []ReadwiseBook{{ID: 3, Title: "Le Petit Prince"}},
+		highlights: map[int][]Highlight{3: {{ID: 30, BookID: 3, Text: "L'essentiel est invisible pour les yeux"}}},
+	})
+	assertMirrorSegments(t, dir, 2)
+
+	// Nothing changed, so nothing is written
+	sync("kindle", staticProvider{
+		books:      []ReadwiseBook{deepWork, walden},
+		highlights: map[int][]Highlight{1: {focus}, 2: {simplify, woods}},
+	})
+	assertMirrorSegments(t, dir, 2)
+
+	// An edited highlight, a deleted highlight and a deleted book. The Kobo book is not deleted by the Kindle.
+	edited := focus
+	edited.Note = "Quote this"
+	sync("kindle", staticProvider{
+		books:      []ReadwiseBook{deepWork},
+		highlights: map[int][]Highlight{1: {edited}},
+	})
+	assertMirrorSegments(t, dir, 3)
+	assertMirrorLibrary(t, dir, map[string][]string{
+		"Deep Work":       {"Focus is rare: Quote this"},
+		"Le Petit Prince": {"L'essentiel est invisible pour les yeux"},
+	})
+
+	// Walden comes back with one of its highlights
+	sync("kindle", staticProvider{
+		books:      []ReadwiseBook{deepWork, walden},
+		highlights: map[int][]Highlight{1: {edited}, 2: {woods}},
+	})
+	assertMirrorSegments(t, dir, 4)
+	assertMirrorLibrary(t, dir, map[string][]string{
+		"Deep Work":       {"Focus is rare: Quote this"},
+		"Walden":          {"I went to the woods"},
+		"Le Petit Prince": {"L'essentiel est invisible pour les yeux"},
+	})
+
+	// The other highlight is restored too, in its place
+	sync("kindle", staticProvider{
+		books:      []ReadwiseBook{deepWork, walden},
+		highlights: map[int][]Highlight{1: {edited}, 2: {simplify, woods}},
+	})
+	assertMirrorSegments(t, dir, 5)
+	assertMirrorLibrary(t, dir, map[string][]string{
+		"Deep Work":       {"Focus is rare: Quote this"},
+		"Walden":          {"Simplify, simplify", "I went to the woods"},
+		"Le Petit Prince": {"L'essentiel est invisible pour les yeux"},
+	})
+}
+
+func assertMirrorSegments(t *testing.T, dir string, want int) {
+	t.Helper()
+	segments, err := filepath.Glob(filepath.Join(dir, "*.ndjson"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segments) != want {
+		t.Fatalf("got %d segments, want %d", len(segments), want)
+	}
+	for _, segment := range segments {
+		if info, err := os.Stat(segment); err != nil || info.Size() == 0 {
+			t.Errorf("got an empty segment %s", filepath.Base(segment))
+		}
+	}
+}
+
+// assertMirrorLibrary replays the mirror and compares its books with the highlights and notes they hold
+func assertMirrorLibrary(t *testing.T, dir string, want map[string][]string) {
+	t.Helper()
+	provider := NewMirrorProvider(dir)
+	books, err := provider.GetBooks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(books) != len(want) {
+		t.Errorf("got %d books, want %d", len(books), len(want))
+	}
+	for _, book := range books {
+		highlights, err := provider.GetHighlights(book.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, highlight := range highlights {
+			text := highlight.Text
+			if highlight.Note != "" {
+				text += ": " + highlight.Note
+			}
+			got = append(got, text)
+		}
+		if len(got) != len(want[book.Title]) {
+			t.Errorf("got highlights %q for %s, want %q", got, book.Title, want[book.Title])
+			continue
+		}
+		for i := range got {
+			if got[i] != want[book.Title][i] {
+				t.Errorf("got highlights %q for %s, want %q", got, book.Title, want[book.Title])
+				break
+			}
+		}
+	}
+}
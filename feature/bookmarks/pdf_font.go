@@ -0,0 +1,403 @@
+package bookmarks
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// pdfFont maps the character codes of a font to text and widths
+type pdfFont struct {
+	composite    bool
+	codespace    []pdfCodespaceRange
+	toUnicode    map[string]string
+	encoding     [256]string
+	unicodeCodes bool
+	widths       map[int]float64
+	defaultWidth float64
+	// widthScale converts glyph space to text space, 1/1000 except for Type3 fonts
+	widthScale float64
+}
+
+// pdfCode is a character code read from a string
+type pdfCode struct {
+	code   int
+	length int
+	raw    string
+}
+
+type pdfCodespaceRange struct {
+	low, high string
+}
+
+func newPDFFont(doc *pdfDocument, dict pdfDict) *pdfFont {
+	font := &pdfFont{
+		widths:       make(map[int]float64),
+		defaultWidth: 500,
+		widthScale:   0.001,
+	}
+
+	if stream, ok := doc.resolve(dict["ToUnicode"]).(*pdfStream); ok {
+		if data, err := doc.decodeStream(stream); err == nil {
+			font.parseCMap(data)
+		}
+	}
+
+	if dict["Subtype"] == pdfName("Type0") {
+		font.composite = true
+		font.loadCIDWidths(doc, doc.dict(doc.array(dict["DescendantFonts"]).first()))
+		switch encoding := doc.resolve(dict["Encoding"]).(type) {
+		case pdfName:
+			// Unicode CMaps use the UTF-16 code units as character codes
+			font.unicodeCodes = strings.Contains(string(encoding), "UCS2") || strings.Contains(string(encoding), "UTF16")
+		case *pdfStream:
+			// Embedded CMaps define the code lengths when the ToUnicode CMap doesn't
+			if data, err := doc.decodeStream(encoding); err == nil && font.codespace == nil {
+				font.parseCMap(data)
+			}
+		}
+		return font
+	}
+
+	font.loadSimpleWidths(doc, dict)
+	font.loadEncoding(doc, dict)
+	return font
+}
+
+func (a pdfArray) first() interface{} {
+	if len(a) == 0 {
+		return nil
+	}
+	return a[0]
+}
+
+// loadSimpleWidths reads the widths of a simple font, indexed from FirstChar
+func (f *pdfFont) loadSimpleWidths(doc *pdfDocument, dict pdfDict) {
+	if dict["Subtype"] == pdfName("Type3") {
+		if matrix := doc.numbers(dict["FontMatrix"]); len(matrix) == 6 {
+			f.widthScale = matrix[0]
+		}
+	}
+	if missing, ok := doc.number(doc.dict(dict["FontDescriptor"])["MissingWidth"]); ok && missing > 0 {
+		f.defaultWidth = missing
+	}
+	first, _ := doc.number(dict["FirstChar"])
+	for i, width := range doc.numbers(dict["Widths"]) {
+		f.widths[int(first)+i] = width
+	}
+}
+
+// loadCIDWidths reads the W array of a CID font: "c [w1 w2 ...]" or "cfirst clast w"
+func (f *pdfFont) loadCIDWidths(doc *pdfDocument, descendant pdfDict) {
+	f.defaultWidth = 1000
+	if width, ok := doc.number(descendant["DW"]); ok {
+		f.defaultWidth = width
+	}
+
+	entries := doc.array(descendant["W"])
+	for i := 0; i < len(entries); {
+		first, ok := doc.number(entries[i])
+		if !ok || i+1 >= len(entries) {
+			return
+		}
+		if widths, ok := doc.resolve(entries[i+1]).(pdfArray); ok {
+			for j, width := range widths {
+				if value, ok := doc.number(width); ok {
+					f.widths[int(first)+j] = value
+				}
+			}
+			i += 2
+			continue
+		}
+		last, ok1 := doc.number(entries[i+1])
+		width, ok2 := doc.number(entries[min(i+2, len(entries)-1)])
+		if !ok1 || !ok2 || last-first > 65535 {
+			return
+		}
+		for code := int(first); code <= int(last); code++ {
+			f.widths[code] = width
+		}
+		i += 3
+	}
+}
+
+// loadEncoding builds the code to text table of a simple font from its base encoding and differences
+func (f *pdfFont) loadEncoding(doc *pdfDocument, dict pdfDict) {
+	base := pdfName("WinAnsiEncoding")
+	var differences pdfArray
+	switch encoding := doc.resolve(dict["Encoding"]).(type) {
+	case pdfName:
+		base = encoding
+	case pdfDict:
+		if name, ok := doc.resolve(encoding["BaseEncoding"]).(pdfName); ok {
+			base = name
+		}
+		differences = doc.array(encoding["Differences"])
+	}
+
+	for code := 0; code < 256; code++ {
+		if base == "MacRomanEncoding" && code >= 0x80 {
+			f.encoding[code] = string([]rune(macRomanHigh)[code-0x80])
+		} else {
+			f.encoding[code] = winAnsiText(code)
+		}
+	}
+
+	code := 0
+	for _, item := range differences {
+		switch value := doc.resolve(item).(type) {
+		case float64:
+			code = int(value)
+		case pdfName:
+			if code >= 0 && code < 256 {
+				f.encoding[code] = glyphText(string(value))
+			}
+			code++
+		}
+	}
+}
+
+// codes splits a string into character codes
+func (f *pdfFont) codes(s string) []pdfCode {
+	var codes []pdfCode
+	for i := 0; i < len(s); {
+		length := 1
+		if f.composite {
+			length = f.codeLength(s[i:])
+		}
+		if i+length > len(s) {
+			length = len(s) - i
+		}
+		raw := s[i : i+length]
+		codes = append(codes, pdfCode{code: pdfBigEndian([]byte(raw)), length: length, raw: raw})
+		i += length
+	}
+	return codes
+}
+
+// codeLength finds the length of the next code from the codespace ranges, two bytes by default
+func (f *pdfFont) codeLength(s string) int {
+	for _, r := range f.codespace {
+		if len(r.low) > len(s) {
+			continue
+		}
+		matches := true
+		for i := 0; i < len(r.low); i++ {
+			if s[i] < r.low[i] || s[i] > r.high[i] {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return len(r.low)
+		}
+	}
+	return 2
+}
+
+func (f *pdfFont) width(code int) float64 {
+	width, ok := f.widths[code]
+	if !ok {
+		width = f.defaultWidth
+	}
+	return width * f.widthScale
+}
+
+func (f *pdfFont) text(code pdfCode) string {
+	if text, ok := f.toUnicode[code.raw]; ok {
+		return text
+	}
+	switch {
+	case f.unicodeCodes:
+		return pdfUTF16(code.raw)
+	case !f.composite && code.code < 256:
+		return f.encoding[code.code]
+	}
+	return ""
+}
+
+// maxCMapRange limits the number of codes a single bfrange can define
+const maxCMapRange = 65536
+
+// parseCMap reads the codespace ranges and Unicode mappings of a CMap
+func (f *pdfFont) parseCMap(data []byte) {
+	if f.toUnicode == nil {
+		f.toUnicode = make(map[string]string)
+	}
+	lexer := &pdfLexer{data: data}
+	for lexer.pos < len(lexer.data) {
+		keyword, ok := lexer.next().(pdfKeyword)
+		if !ok {
+			continue
+		}
+		switch keyword {
+		case "begincodespacerange":
+			for {
+				low, ok := lexer.next().(pdfString)
+				if !ok {
+					break
+				}
+				high, _ := lexer.next().(pdfString)
+				if len(low) == len(high) && len(low) > 0 {
+					f.codespace = append(f.codespace, pdfCodespaceRange{low: string(low), high: string(high)})
+				}
+			}
+		case "beginbfchar":
+			for {
+				source, ok := lexer.next().(pdfString)
+				if !ok {
+					break
+				}
+				switch destination := lexer.next().(type) {
+				case pdfString:
+					f.toUnicode[string(source)] = pdfUTF16(string(destination))
+				case pdfName:
+					f.toUnicode[string(source)] = glyphText(string(destination))
+				}
+			}
+		case "beginbfrange":
+			for {
+				low, ok := lexer.next().(pdfString)
+				if !ok {
+					break
+				}
+				high, _ := lexer.next().(pdfString)
+				if len(low) != len(high) {
+					lexer.next()
+					continue
+				}
+				first, last := pdfBigEndian([]byte(low)), pdfBigEndian([]byte(high))
+				if last-first >= maxCMapRange {
+					last = first + maxCMapRange - 1
+				}
+				switch destination := lexer.next().(type) {
+				case pdfString:
+					units := utf16Units(string(destination))
+					for code := first; code <= last && len(units) > 0; code++ {
+						f.toUnicode[pdfCodeBytes(code, len(low))] = string(utf16.Decode(units))
+						units = append([]uint16{}, units...)
+						units[len(units)-1]++
+					}
+				case pdfArray:
+					for i, item := range destination {
+						if text, ok := item.(pdfString); ok && first+i <= last {
+							f.toUnicode[pdfCodeBytes(first+i, len(low))] = pdfUTF16(string(text))
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func utf16Units(value string) []uint16 {
+	units := make([]uint16, 0, len(value)/2)
+	for i := 0; i+1 < len(value); i += 2 {
+		units = append(units, uint16(value[i])<<8|uint16(value[i+1]))
+	}
+	return units
+}
+
+// pdfCodeBytes encodes a code on the given number of bytes
+func pdfCodeBytes(code, length int) string {
+	b := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		b[i] = byte(code)
+		code >>= 8
+	}
+	return string(b)
+}
+
+// winAnsiSpecial is the text of the codes 0x80 to 0x9F of WinAnsiEncoding
+const winAnsiSpecial = "€\x00‚ƒ„…†‡ˆ‰Š‹Œ\x00Ž\x00\x00‘’“”•–—˜™š›œ\x00žŸ"
+
+// macRomanHigh is the text of the codes 0x80 to 0xFF of MacRomanEncoding
+const macRomanHigh = "ÄÅÇÉÑÖÜáàâäãåçéèêëíìîïñóòôöõúùûü†°¢£§•¶ß®©™´¨≠ÆØ∞±≤≥¥µ∂∑∏π∫ªºΩæø¿¡¬√ƒ≈∆«»…\u00a0ÀÃÕŒœ–—“”‘’÷◊ÿŸ⁄€‹›ﬁﬂ‡·‚„‰ÂÊÁËÈÍÎÏÌÓÔ\uf8ffÒÚÛÙıˆ˜¯˘˙˚¸˝˛ˇ"
+
+func winAnsiText(code int) string {
+	switch {
+	case code < 0x20 || code == 0x7f:
+		return ""
+	case code >= 0x80 && code < 0xa0:
+		r := []rune(winAnsiSpecial)[code-0x80]
+		if r == 0 {
+			return ""
+		}
+		return string(r)
+	}
+	return string(rune(code))
+}
+
+// Glyph names of the WinAnsiEncoding characters, from 0x20
+const (
+	asciiGlyphNames = "space exclam quotedbl numbersign dollar percent ampersand quotesingle parenleft parenright asterisk plus comma hyphen period slash " +
+		"zero one two three four five six seven eight nine colon semicolon less equal greater question at " +
+		"A B C D E F G H I J K L M N O P Q R S T U V W X Y Z bracketleft backslash bracketright asciicircum underscore grave " +
+		"a b c d e f g h i j k l m n o p q r s t u v w x y z braceleft bar braceright asciitilde"
+	winAnsiGlyphNames = "Euro - quotesinglbase florin quotedblbase ellipsis dagger daggerdbl circumflex perthousand Scaron guilsinglleft OE - Zcaron - " +
+		"- quoteleft quoteright quotedblleft quotedblright bullet endash emdash tilde trademark scaron guilsinglright oe - zcaron Ydieresis"
+	latin1GlyphNames = "nbspace exclamdown cent sterling currency yen brokenbar section dieresis copyright ordfeminine guillemotleft logicalnot sfthyphen registered macron " +
+		"degree plusminus twosuperior threesuperior acute mu paragraph periodcentered cedilla onesuperior ordmasculine guillemotright onequarter onehalf threequarters questiondown " +
+		"Agrave Aacute Acircumflex Atilde Adieresis Aring AE Ccedilla Egrave Eacute Ecircumflex Edieresis Igrave Iacute Icircumflex Idieresis " +
+		"Eth Ntilde Ograve Oacute Ocircumflex Otilde Odieresis multiply Oslash Ugrave Uacute Ucircumflex Udieresis Yacute Thorn germandbls " +
+		"agrave aacute acircumflex atilde adieresis aring ae ccedilla egrave eacute ecircumflex edieresis igrave iacute icircumflex idieresis " +
+		"eth ntilde ograve oacute ocircumflex otilde odieresis divide oslash ugrave uacute ucircumflex udieresis yacute thorn ydieresis"
+)
+
+// glyphNames maps the common glyph names to their text
+var glyphNames = func() map[string]string {
+	names := map[string]string{
+		"fi": "fi", "fl": "fl", "ff": "ff", "ffi": "ffi", "ffl": "ffl",
+		"minus": "−", "fraction": "⁄", "dotlessi": "ı", "Lslash": "Ł", "lslash": "ł",
+		"ring": "˚", "breve": "˘", "dotaccent": "˙", "hungarumlaut": "˝", "ogonek": "˛", "caron": "ˇ",
+		"quotesingle": "'", "grave": "`", "nbspace": " ", "sfthyphen": "-", "periodcentered": "·",
+	}
+	for i, name := range strings.Fields(asciiGlyphNames) {
+		names[name] = string(rune(0x20 + i))
+	}
+	for i, name := range strings.Fields(winAnsiGlyphNames) {
+		if name != "-" {
+			names[name] = winAnsiText(0x80 + i)
+		}
+	}
+	for i, name := range strings.Fields(latin1GlyphNames) {
+		if _, exists := names[name]; !exists {
+			names[name] = string(rune(0xa0 + i))
+		}
+	}
+	return names
+}()
+
+// glyphText returns the text of a glyph name: a known name, uniXXXX, uXXXX[XX], or ligatures joined with "_"
+func glyphText(name string) string {
+	if text, ok := glyphNames[name]; ok {
+		return text
+	}
+	if base, _, found := strings.Cut(name, "."); found && base != "" {
+		return glyphText(base)
+	}
+	if strings.Contains(name, "_") {
+		var b strings.Builder
+		for _, part := range strings.Split(name, "_") {
+			b.WriteString(glyphText(part))
+		}
+		return b.String()
+	}
+	if strings.HasPrefix(name, "uni") && len(name) >= 7 && (len(name)-3)%4 == 0 {
+		var units []uint16
+		for i := 3; i < len(name); i += 4 {
+			value, err := strconv.ParseUint(name[i:i+4], 16, 16)
+			if err != nil {
+				return ""
+			}
+			units = append(units, uint16(value))
+		}
+		return string(utf16.Decode(units))
+	}
+	if strings.HasPrefix(name, "u") && len(name) >= 5 && len(name) <= 7 {
+		if value, err := strconv.ParseUint(name[1:], 16, 32); err == nil {
+			return string(rune(value))
+		}
+	}
+	return ""
+}
@@ -0,0 +1,530 @@
+package bookmarks
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PDFAnnotationsProvider implements the BookmarksProvider interface by reading the highlight
+// annotations embedded in PDF files, as written by most desktop and tablet PDF readers
+type PDFAnnotationsProvider struct {
+	path string
+	localLibrary
+}
+
+// NewPDFAnnotationsProvider creates a new PDFAnnotationsProvider for the given directory or PDF file
+func NewPDFAnnotationsProvider(path string) *PDFAnnotationsProvider {
+	p := &PDFAnnotationsProvider{path: path}
+	p.load = p.parse
+	return p
+}
+
+// pdfMarkupTypes are the annotations that mark text
+var pdfMarkupTypes = map[pdfName]bool{"Highlight": true, "Underline": true, "Squiggly": true, "StrikeOut": true}
+
+// pdfPage is a page with the resources it inherits
+type pdfPage struct {
+	dict      pdfDict
+	resources pdfDict
+}
+
+func (p *PDFAnnotationsProvider) parse() (*libraryBuilder, error) {
+	files, err := pdfFiles(p.path)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := newLibraryBuilder()
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read PDF %s: %w", file, err)
+		}
+		book, highlights, err := readPDF(data, file, builder)
+		if errors.Is(err, errPDFEncrypted) {
+			fmt.Printf("Warning: skipping encrypted PDF %s\n", file)
+			continue
+		}
+		if err != nil {
+			fmt.Printf("Warning: skipping PDF %s: %v\n", file, err)
+			continue
+		}
+		if len(highlights) == 0 {
+			continue
+		}
+
+		added := builder.addBook(book)
+		for _, highlight := range highlights {
+			builder.addHighlight(added.ID, highlight)
+		}
+	}
+	return builder, nil
+}
+
+// readPDF reads the book and highlights of a PDF file. A file the parser chokes on
+// is reported as an error, so that one corrupt PDF doesn't stop the others.
+func readPDF(data []byte, file string, builder *libraryBuilder) (book ReadwiseBook, highlights []Highlight, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed PDF: %v", r)
+		}
+	}()
+
+	doc, err := openPDF(data)
+	if err != nil {
+		return book, nil, err
+	}
+	book = pdfBook(doc, file)
+	// Copies of the same document keep their own highlights
+	if _, exists := builder.books[book.ID]; exists {
+		book.ID = syntheticID("pdf", file)
+	}
+	return book, pdfHighlights(doc, book.ID), nil
+}
+
+// pdfFiles finds the PDF files under the given path
+func pdfFiles(root string) ([]string, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF directory: %w", err)
+	}
+	if !info.IsDir() {
+		return []string{root}, nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && strings.EqualFold(filepath.Ext(path), ".pdf") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan PDF directory: %w", err)
+	}
+	return files, nil
+}
+
+// pdfBook reads the title and authors from the document information, then XMP metadata, then the file name
+func pdfBook(doc *pdfDocument, file string) ReadwiseBook {
+	info := doc.dict(doc.trailer["Info"])
+	catalog := doc.dict(doc.trailer["Root"])
+	xmpTitle, xmpCreators := "", []string(nil)
+	if stream, ok := doc.resolve(catalog["Metadata"]).(*pdfStream); ok {
+		if data, err := doc.decodeStream(stream); err == nil {
+			xmpTitle, xmpCreators = parseXMP(data)
+		}
+	}
+
+	title := firstNonEmpty(strings.TrimSpace(doc.text(info["Title"])), xmpTitle)
+	if title == "" {
+		title = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	}
+	author := firstNonEmpty(strings.TrimSpace(doc.text(info["Author"])), strings.Join(xmpCreators, "; "))
+
+	// The first part of the file identifier stays the same when the document is modified
+	key := file
+	if ids := doc.array(doc.trailer["ID"]); len(ids) > 0 {
+		if id, ok := doc.resolve(ids[0]).(pdfString); ok && len(id) > 0 {
+			key = hex.EncodeToString([]byte(id))
+		}
+	}
+
+	return ReadwiseBook{
+		ID:       syntheticID("pdf", key),
+		Title:    title,
+		Author:   author,
+		Category: "books",
+		Source:   "pdf",
+		Language: doc.text(catalog["Lang"]),
+	}
+}
+
+// pdfHighlights reads the text markup and note annotations of every page, in reading order
+func pdfHighlights(doc *pdfDocument, bookID int) []Highlight {
+	type positioned struct {
+		highlight Highlight
+		page      int
+		top, left float64
+	}
+	var found []positioned
+
+	pages := pdfPages(doc)
+	labels := newPDFPageLabels(doc)
+	extractor := newPDFTextExtractor(doc)
+	for index, page := range pages {
+		annotations := doc.array(page.dict["Annots"])
+		if len(annotations) == 0 {
+			continue
+		}
+
+		// Replies to an annotation are added to its note
+		replies := make(map[pdfRef][]string)
+		for _, item := range annotations {
+			annotation := doc.dict(item)
+			if parent, ok := annotation["IRT"].(pdfRef); ok {
+				if text := strings.TrimSpace(doc.text(annotation["Contents"])); text != "" {
+					replies[parent] = append(replies[parent], text)
+				}
+			}
+		}
+
+		var glyphs []pdfGlyph
+		glyphsLoaded := false
+		for _, item := range annotations {
+			annotation := doc.dict(item)
+			subtype, _ := doc.resolve(annotation["Subtype"]).(pdfName)
+			if annotation["IRT"] != nil || (!pdfMarkupTypes[subtype] && subtype != "Text") {
+				continue
+			}
+
+			contents := strings.TrimSpace(doc.text(annotation["Contents"]))
+			if contents == "" {
+				contents = strings.TrimSpace(doc.text(doc.dict(annotation["Popup"])["Contents"]))
+			}
+			rect := doc.numbers(annotation["Rect"])
+			if len(rect) != 4 {
+				continue
+			}
+
+			text, note := contents, ""
+			if pdfMarkupTypes[subtype] {
+				if !glyphsLoaded {
+					glyphs = extractor.pageGlyphs(page.dict, page.resources)
+					glyphsLoaded = true
+				}
+				quads := doc.numbers(annotation["QuadPoints"])
+				if len(quads) < 8 {
+					quads = []float64{rect[0], rect[3], rect[2], rect[3], rect[0], rect[1], rect[2], rect[1]}
+				}
+				// Some readers also store the highlighted text as the contents
+				if recovered := textInQuads(glyphs, quads); recovered != "" {
+					text = recovered
+					if normalizeKey(contents) != normalizeKey(recovered) {
+						note = contents
+					}
+				}
+			}
+			if ref, ok := item.(pdfRef); ok && len(replies[ref]) > 0 {
+				note = strings.TrimSpace(strings.Join(append([]string{note}, replies[ref]...), "\n\n"))
+			}
+			if text == "" {
+				continue
+			}
+
+			created := parsePDFDate(doc.text(annotation["CreationDate"]))
+			modified := parsePDFDate(doc.text(annotation["M"]))
+			if created.IsZero() {
+				created = modified
+			}
+			if modified.IsZero() {
+				modified = created
+			}
+
+			id := doc.text(annotation["NM"])
+			if id == "" {
+				id = fmt.Sprintf("%d:%.0f:%.0f:%.0f:%.0f", index, rect[0], rect[1], rect[2], rect[3])
+			}
+
+			found = append(found, positioned{
+				page: index,
+				top:  math.Max(rect[1], rect[3]),
+				left: math.Min(rect[0], rect[2]),
+				highlight: Highlight{
+					ID:            syntheticID("pdf", strconv.Itoa(bookID), id),
+					Text:          text,
+					Note:          note,
+					Color:         pdfColorName(doc.numbers(annotation["C"])),
+					Location:      index + 1,
+					EndLocation:   index + 1,
+					LocationType:  "page",
+					PageLabel:     labels.label(index),
+					HighlightedAt: created,
+					CreatedAt:     created,
+					Updated:       modified,
+					ExternalID:    doc.text(annotation["NM"]),
+				},
+			})
+		}
+	}
+
+	// Page by page, from the top of the page
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].page != found[j].page {
+			return found[i].page < found[j].page
+		}
+		if math.Abs(found[i].top-found[j].top) > 2 {
+			return found[i].top > found[j].top
+		}
+		return found[i].left < found[j].left
+	})
+	highlights := make([]Highlight, 0, len(found))
+	for _, f := range found {
+		highlights = append(highlights, f.highlight)
+	}
+	return highlights
+}
+
+// pdfPages walks the page tree, passing the inherited resources down
+func pdfPages(doc *pdfDocument) []pdfPage {
+	var pages []pdfPage
+	visited := make(map[pdfRef]bool)
+	var walk func(node interface{}, resources pdfDict, depth int)
+	walk = func(node interface{}, resources pdfDict, depth int) {
+		if ref, ok := node.(pdfRef); ok {
+			if visited[ref] {
+				return
+			}
+			visited[ref] = true
+		}
+		dict := doc.dict(node)
+		if dict == nil || depth > 64 {
+			return
+		}
+		if own := doc.dict(dict["Resources"]); own != nil {
+			resources = own
+		}
+		kids, isTree := doc.resolve(dict["Kids"]).(pdfArray)
+		if !isTree || dict["Type"] == pdfName("Page") {
+			pages = append(pages, pdfPage{dict: dict, resources: resources})
+			return
+		}
+		for _, kid := range kids {
+			walk(kid, resources, depth+1)
+		}
+	}
+	walk(doc.dict(doc.trailer["Root"])["Pages"], nil, 0)
+	return pages
+}
+
+// pdfPageLabels are the page numbers shown by readers, like "iv" or "A-3"
+type pdfPageLabels struct {
+	starts []int
+	ranges []pdfDict
+	doc    *pdfDocument
+}
+
+func newPDFPageLabels(doc *pdfDocument) *pdfPageLabels {
+	labels := &pdfPageLabels{doc: doc}
+	var walk func(node pdfDict, depth int)
+	walk = func(node pdfDict, depth int) {
+		if node == nil || depth > 32 {
+			return
+		}
+		nums := doc.array(node["Nums"])
+		for i := 0; i+1 < len(nums); i += 2 {
+			if start, ok := doc.number(nums[i]); ok {
+				labels.starts = append(labels.starts, int(start))
+				labels.ranges = append(labels.ranges, doc.dict(nums[i+1]))
+			}
+		}
+		for _, kid := range doc.array(node["Kids"]) {
+			walk(doc.dict(kid), depth+1)
+		}
+	}
+	walk(doc.dict(doc.dict(doc.trailer["Root"])["PageLabels"]), 0)
+	return labels
+}
+
+// label returns the label of a page index, or "" when the document defines none
+func (l *pdfPageLabels) label(index int) string {
+	current := -1
+	for i, start := range l.starts {
+		if start <= index && (current < 0 || start >= l.starts[current]) {
+			current = i
+		}
+	}
+	if current < 0 {
+		return ""
+	}
+
+	labelRange := l.ranges[current]
+	first := 1.0
+	if value, ok := l.doc.number(labelRange["St"]); ok {
+		first = value
+	}
+	number := int(first) + index - l.starts[current]
+	prefix := l.doc.text(labelRange["P"])
+
+	switch l.doc.resolve(labelRange["S"]) {
+	case pdfName("D"):
+		return prefix + strconv.Itoa(number)
+	case pdfName("R"):
+		return prefix + strings.ToUpper(romanNumeral(number))
+	case pdfName("r"):
+		return prefix + romanNumeral(number)
+	case pdfName("A"):
+		return prefix + strings.ToUpper(letterNumeral(number))
+	case pdfName("a"):
+		return prefix + letterNumeral(number)
+	}
+	return prefix
+}
+
+func romanNumeral(number int) string {
+	values := []int{1000, 900, 500, 400, 100, 90, 50, 40, 10, 9, 5, 4, 1}
+	symbols := []string{"m", "cm", "d", "cd", "c", "xc", "l", "xl", "x", "ix", "v", "iv", "i"}
+	var b strings.Builder
+	for i, value := range values {
+		for number >= value {
+			b.WriteString(symbols[i])
+			number -= value
+		}
+	}
+	return b.String()
+}
+
+// letterNumeral numbers pages a to z, then aa to zz, and so on
+func letterNumeral(number int) string {
+	if number < 1 {
+		return ""
+	}
+	letter := string(rune('a' + (number-1)%26))
+	return strings.Repeat(letter, (number-1)/26+1)
+}
+
+// parseXMP reads the Dublin Core title and creators of an XMP packet
+func parseXMP(data []byte) (string, []string) {
+	const dublinCore = "http://purl.org/dc/elements/1.1/"
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+
+	var title string
+	var creators []string
+	field, inItem := "", false
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Space == dublinCore && (t.Name.Local == "title" || t.Name.Local == "creator") {
+				field = t.Name.Local
+			} else if field != "" && t.Name.Local == "li" {
+				inItem = true
+				text.Reset()
+			}
+		case xml.CharData:
+			if inItem {
+				text.Write(t)
+			}
+		case xml.EndElement:
+			if t.Name.Local == "li" && inItem {
+				inItem = false
+				value := strings.TrimSpace(text.String())
+				switch {
+				case value == "":
+				case field == "title" && title == "":
+					title = value
+				case field == "creator":
+					creators = append(creators, value)
+				}
+			} else if t.Name.Space == dublinCore && t.Name.Local == field {
+				field = ""
+			}
+		}
+	}
+	return title, creators
+}
+
+// parsePDFDate parses dates like "D:20230501101112+02'00'", where everything after the year is optional
+func parsePDFDate(value string) time.Time {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "D:")
+	digits := 0
+	for digits < len(value) && digits < 14 && value[digits] >= '0' && value[digits] <= '9' {
+		digits++
+	}
+	if digits < 4 {
+		return time.Time{}
+	}
+
+	parts := []int{0, 1, 1, 0, 0, 0}
+	widths := []int{4, 2, 2, 2, 2, 2}
+	position := 0
+	for i, width := range widths {
+		if position+width > digits {
+			break
+		}
+		parts[i], _ = strconv.Atoi(value[position : position+width])
+		position += width
+	}
+
+	location := time.UTC
+	zone := strings.ReplaceAll(value[digits:], "'", "")
+	if len(zone) >= 3 && (zone[0] == '+' || zone[0] == '-') {
+		hours, _ := strconv.Atoi(zone[1:3])
+		minutes := 0
+		if len(zone) >= 5 {
+			minutes, _ = strconv.Atoi(zone[3:5])
+		}
+		offset := hours*3600 + minutes*60
+		if zone[0] == '-' {
+			offset = -offset
+		}
+		location = time.FixedZone("", offset)
+	}
+	return time.Date(parts[0], time.Month(parts[1]), parts[2], parts[3], parts[4], parts[5], 0, location)
+}
+
+// pdfColorName names the color of an annotation from its gray, RGB or CMYK components
+func pdfColorName(components []float64) string {
+	var r, g, b float64
+	switch len(components) {
+	case 1:
+		r, g, b = components[0], components[0], components[0]
+	case 3:
+		r, g, b = components[0], components[1], components[2]
+	case 4:
+		c, m, y, k := components[0], components[1], components[2], components[3]
+		r, g, b = (1-c)*(1-k), (1-m)*(1-k), (1-y)*(1-k)
+	default:
+		return ""
+	}
+
+	high, low := math.Max(r, math.Max(g, b)), math.Min(r, math.Min(g, b))
+	if high-low < 0.15 {
+		return "gray"
+	}
+	var hue float64
+	switch high {
+	case r:
+		hue = math.Mod((g-b)/(high-low)*60+360, 360)
+	case g:
+		hue = (b-r)/(high-low)*60 + 120
+	default:
+		hue = (r-g)/(high-low)*60 + 240
+	}
+
+	switch {
+	case hue < 15 || hue >= 345:
+		return "red"
+	case hue < 40:
+		return "orange"
+	case hue < 70:
+		return "yellow"
+	case hue < 170:
+		return "green"
+	case hue < 260:
+		return "blue"
+	case hue < 300:
+		return "purple"
+	default:
+		return "pink"
+	}
+}
@@ -0,0 +1,119 @@
+package bookmarks
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadPDF(t *testing.T) {
+	tests := []struct {
+		file       string
+		title      string
+		author     string
+		highlights []Highlight
+		err        error
+	}{
+		{
+			file:   "highlights.pdf",
+			title:  "XMP Title",
+			author: "Info Author",
+			highlights: []Highlight{
+				{Text: "this is a highlighted sentence on the first page.", Note: "Great point\n\nA reply", Color: "yellow", PageLabel: "i", Location: 1},
+				{Text: "Other text", Note: "popup note", Color: "blue", PageLabel: "i", Location: 1},
+				{Text: "Stichy ก", PageLabel: "A-5", Location: 2},
+			},
+		},
+		{
+			// The cross-reference offset is wrong, objects are found by scanning the file
+			file:   "broken_xref.pdf",
+			title:  "XMP Title",
+			author: "Info Author",
+			highlights: []Highlight{
+				{Text: "this is a highlighted sentence on the first page.", Note: "Great point\n\nA reply", Color: "yellow", PageLabel: "i", Location: 1},
+				{Text: "Other text", Note: "popup note", Color: "blue", PageLabel: "i", Location: 1},
+				{Text: "Stichy ก", PageLabel: "A-5", Location: 2},
+			},
+		},
+		{
+			// Cross-reference stream with a PNG predictor, and objects in an object stream
+			file:  "xref_stream.pdf",
+			title: "xref_stream",
+			highlights: []Highlight{
+				{Text: "Compressed objects work", Color: "green", Location: 1},
+			},
+		},
+		{file: "encrypted.pdf", err: errPDFEncrypted},
+	}
+
+	for _, test := range tests {
+		t.Run(test.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", "pdf", test.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			book, highlights, err := readPDF(data, test.file, newLibraryBuilder())
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("got error %v, want %v", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if book.Title != test.title || book.Author != test.author {
+				t.Errorf("got book %q by %q, want %q by %q", book.Title, book.Author, test.title, test.author)
+			}
+			if len(highlights) != len(test.highlights) {
+				t.Fatalf("got %d highlights, want %d", len(highlights), len(test.highlights))
+			}
+			for i, want := range test.highlights {
+				got := highlights[i]
+				if got.Text != want.Text || got.Note != want.Note || got.Color != want.Color || got.PageLabel != want.PageLabel || got.Location != want.Location {
+					t.Errorf("highlight %d: got %q %q %q %q %d, want %q %q %q %q %d", i,
+						got.Text, got.Note, got.Color, got.PageLabel, got.Location,
+						want.Text, want.Note, want.Color, want.PageLabel, want.Location)
+				}
+			}
+		})
+	}
+}
+
+func TestReadPDFMalformed(t *testing.T) {
+	inputs := []string{
+		"%PDF 0 0 obj<<<0",
+		"%PDF-1.7\n1 0 obj\n<< /Length 99999999999999999999 >>\nstream\nabc\nendstream\nendobj\ntrailer << /Root 1 0 R >>",
+		"%PDF-1.7\nstartxref\n-5\n%%EOF",
+		"%PDF-1.7\n1 0 obj\n<< /Type /XRef /W [0 0 0] /Size 3 /Root 1 0 R /Length 3 >>\nstream\nabc\nendstream\nendobj\nstartxref\n9\n%%EOF",
+		"%PDF-1.7\n1 0 obj\n<< /Type /XRef /W [-1 4 9] /Size 3 /Root 1 0 R /Length 3 >>\nstream\nabc\nendstream\nendobj\nstartxref\n9\n%%EOF",
+	}
+	for _, input := range inputs {
+		// Errors are expected, panics are not
+		readPDF([]byte(input), "malformed.pdf", newLibraryBuilder())
+	}
+}
+
+func FuzzOpenPDF(f *testing.F) {
+	files, _ := filepath.Glob(filepath.Join("testdata", "pdf", "*.pdf"))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	f.Add([]byte("%PDF 0 0 obj<<<0"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Without the recover of readPDF, so that panics are reported
+		doc, err := openPDF(data)
+		if err != nil {
+			return
+		}
+		book := pdfBook(doc, "fuzz.pdf")
+		pdfHighlights(doc, book.ID)
+	})
+}
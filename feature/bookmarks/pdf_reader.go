@@ -0,0 +1,909 @@
+package bookmarks
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"unicode/utf16"
+)
+
+// pdfDocument is a minimal read-only PDF parser: it resolves objects through the cross-reference
+// table or stream, object streams included, and decodes the common stream filters.
+// It is meant for reading annotations, text and metadata, not for rendering.
+type pdfDocument struct {
+	data       []byte
+	offsets    map[int]int
+	compressed map[int]pdfCompressedRef
+	cache      map[int]interface{}
+	resolving  map[int]bool
+	trailer    pdfDict
+}
+
+// PDF object types. Numbers are float64, booleans bool and null nil.
+type (
+	pdfName    string
+	pdfString  string
+	pdfKeyword string
+	pdfArray   []interface{}
+	pdfDict    map[pdfName]interface{}
+	pdfRef     struct{ num, gen int }
+	pdfStream  struct {
+		dict pdfDict
+		data []byte
+	}
+)
+
+// pdfCompressedRef locates an object stored in an object stream
+type pdfCompressedRef struct {
+	stream int
+	index  int
+}
+
+var (
+	errPDFEncrypted  = errors.New("encrypted PDFs are not supported")
+	pdfObjectPattern = regexp.MustCompile(`(?m)(?:^|[\r\n\s])(\d+)\s+(\d+)\s+obj\b`)
+)
+
+// openPDF parses the cross-reference data of a PDF file. When it is broken,
+// the objects are found by scanning the file instead.
+func openPDF(data []byte) (*pdfDocument, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, "\x00\t\n\f\r "), []byte("%PDF")) {
+		return nil, fmt.Errorf("not a PDF file")
+	}
+
+	doc := &pdfDocument{
+		data:       data,
+		offsets:    make(map[int]int),
+		compressed: make(map[int]pdfCompressedRef),
+		cache:      make(map[int]interface{}),
+		resolving:  make(map[int]bool),
+	}
+	if err := doc.readXref(); err != nil || doc.trailer["Root"] == nil {
+		doc.offsets = make(map[int]int)
+		doc.compressed = make(map[int]pdfCompressedRef)
+		doc.trailer = nil
+		if err := doc.scanObjects(); err != nil {
+			return nil, err
+		}
+	}
+	if doc.trailer["Encrypt"] != nil {
+		return nil, errPDFEncrypted
+	}
+	return doc, nil
+}
+
+// readXref follows the cross-reference sections from the last one, newer entries taking precedence
+func (doc *pdfDocument) readXref() error {
+	index := bytes.LastIndex(doc.data, []byte("startxref"))
+	if index < 0 {
+		return fmt.Errorf("startxref not found")
+	}
+	lexer := &pdfLexer{data: doc.data, pos: index + len("startxref")}
+	offset, ok := lexer.next().(float64)
+	if !ok {
+		return fmt.Errorf("invalid startxref")
+	}
+
+	seen := make(map[int]bool)
+	for position := int(offset); position > 0 && !seen[position]; {
+		seen[position] = true
+		trailer, err := doc.readXrefSection(position)
+		if err != nil {
+			return err
+		}
+		if doc.trailer == nil {
+			doc.trailer = trailer
+		}
+		// Hybrid files keep the compressed objects in an additional stream
+		if stream, ok := trailer["XRefStm"].(float64); ok && !seen[int(stream)] {
+			seen[int(stream)] = true
+			if _, err := doc.readXrefSection(int(stream)); err != nil {
+				return err
+			}
+		}
+		previous, _ := trailer["Prev"].(float64)
+		position = int(previous)
+	}
+	return nil
+}
+
+func (doc *pdfDocument) readXrefSection(position int) (pdfDict, error) {
+	if position < 0 || position >= len(doc.data) {
+		return nil, fmt.Errorf("xref offset out of range")
+	}
+	lexer := &pdfLexer{data: doc.data, pos: position}
+	if keyword, ok := lexer.next().(pdfKeyword); ok && keyword == "xref" {
+		return doc.readXrefTable(lexer)
+	}
+	return doc.readXrefStream(position)
+}
+
+// readXrefTable reads a classic "xref" table and its trailer
+func (doc *pdfDocument) readXrefTable(lexer *pdfLexer) (pdfDict, error) {
+	for {
+		token := lexer.next()
+		if keyword, ok := token.(pdfKeyword); ok && keyword == "trailer" {
+			trailer, ok := lexer.next().(pdfDict)
+			if !ok {
+				return nil, fmt.Errorf("invalid trailer")
+			}
+			return trailer, nil
+		}
+
+		start, ok1 := token.(float64)
+		count, ok2 := lexer.next().(float64)
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("invalid xref table")
+		}
+		for i := 0; i < int(count) && lexer.pos < len(lexer.data); i++ {
+			offset, _ := lexer.next().(float64)
+			lexer.next()
+			kind, _ := lexer.next().(pdfKeyword)
+			if num := int(start) + i; kind == "n" && !doc.known(num) {
+				doc.offsets[num] = int(offset)
+			}
+		}
+	}
+}
+
+// readXrefStream reads a cross-reference stream, whose dictionary is the trailer
+func (doc *pdfDocument) readXrefStream(position int) (pdfDict, error) {
+	lexer := &pdfLexer{data: doc.data, pos: position, doc: doc}
+	object, err := lexer.indirectObject()
+	if err != nil {
+		return nil, err
+	}
+	stream, ok := object.(*pdfStream)
+	if !ok || stream.dict["Type"] != pdfName("XRef") {
+		return nil, fmt.Errorf("invalid xref stream")
+	}
+	data, err := doc.decodeStream(stream)
+	if err != nil {
+		return nil, err
+	}
+
+	widths := doc.numbers(stream.dict["W"])
+	if len(widths) != 3 {
+		return nil, fmt.Errorf("invalid xref stream widths")
+	}
+	// Fields are at most 8 bytes, and an entry must fit the stream
+	for _, width := range widths {
+		if width < 0 || width > 8 || width != float64(int(width)) {
+			return nil, fmt.Errorf("invalid xref stream widths")
+		}
+	}
+	entrySize := int(widths[0] + widths[1] + widths[2])
+	if entrySize == 0 || entrySize > len(data) {
+		return nil, fmt.Errorf("invalid xref stream entry size %d", entrySize)
+	}
+	index := doc.numbers(stream.dict["Index"])
+	if len(index) == 0 {
+		size, _ := stream.dict["Size"].(float64)
+		index = []float64{0, size}
+	}
+
+	position = 0
+	for i := 0; i+1 < len(index); i += 2 {
+		for num := int(index[i]); num < int(index[i]+index[i+1]); num++ {
+			if position+entrySize > len(data) {
+				return stream.dict, nil
+			}
+			entry := data[position : position+entrySize]
+			position += entrySize
+
+			kind := 1
+			if widths[0] > 0 {
+				kind = pdfBigEndian(entry[:int(widths[0])])
+			}
+			field2 := pdfBigEndian(entry[int(widths[0]):int(widths[0]+widths[1])])
+			field3 := pdfBigEndian(entry[int(widths[0]+widths[1]):])
+
+			if doc.known(num) {
+				continue
+			}
+			switch kind {
+			case 1:
+				doc.offsets[num] = field2
+			case 2:
+				doc.compressed[num] = pdfCompressedRef{stream: field2, index: field3}
+			}
+		}
+	}
+	return stream.dict, nil
+}
+
+// known reports whether the location of an object was already read from a newer section
+func (doc *pdfDocument) known(num int) bool {
+	_, inFile := doc.offsets[num]
+	_, inStream := doc.compressed[num]
+	return inFile || inStream
+}
+
+// scanObjects rebuilds the object offsets of a file with a broken cross-reference table
+func (doc *pdfDocument) scanObjects() error {
+	for _, match := range pdfObjectPattern.FindAllSubmatchIndex(doc.data, -1) {
+		num, _ := strconv.Atoi(string(doc.data[match[2]:match[3]]))
+		doc.offsets[num] = match[2]
+	}
+	if len(doc.offsets) == 0 {
+		return fmt.Errorf("no objects found")
+	}
+
+	// Register the objects of the object streams, and look for the trailer entries in
+	// cross-reference streams or, as a last resort, for the catalog
+	var catalog interface{}
+	for num := range doc.offsets {
+		switch object := doc.object(num).(type) {
+		case *pdfStream:
+			if object.dict["Type"] == pdfName("XRef") && object.dict["Root"] != nil {
+				doc.trailer = object.dict
+			}
+			if object.dict["Type"] == pdfName("ObjStm") {
+				doc.scanObjectStream(num)
+			}
+		case pdfDict:
+			if object["Type"] == pdfName("Catalog") {
+				catalog = pdfRef{num: num}
+			}
+		}
+	}
+	for num := range doc.compressed {
+		if dict, ok := doc.object(num).(pdfDict); ok && dict["Type"] == pdfName("Catalog") {
+			catalog = pdfRef{num: num}
+		}
+	}
+
+	// The last trailer wins
+	if index := bytes.LastIndex(doc.data, []byte("trailer")); index >= 0 {
+		lexer := &pdfLexer{data: doc.data, pos: index + len("trailer")}
+		if trailer, ok := lexer.next().(pdfDict); ok && trailer["Root"] != nil {
+			doc.trailer = trailer
+		}
+	}
+	if doc.trailer["Root"] == nil && catalog != nil {
+		doc.trailer = pdfDict{"Root": catalog}
+	}
+	if doc.trailer["Root"] == nil {
+		return fmt.Errorf("document catalog not found")
+	}
+	return nil
+}
+
+// scanObjectStream registers the objects of an object stream found while scanning
+func (doc *pdfDocument) scanObjectStream(num int) {
+	numbers, _, err := doc.objectStreamHeader(num)
+	if err != nil {
+		return
+	}
+	for i, objectNum := range numbers {
+		if !doc.known(objectNum) {
+			doc.compressed[objectNum] = pdfCompressedRef{stream: num, index: i}
+		}
+	}
+}
+
+// resolve follows references, returning the referenced object
+func (doc *pdfDocument) resolve(object interface{}) interface{} {
+	for i := 0; i < 32; i++ {
+		ref, ok := object.(pdfRef)
+		if !ok {
+			return object
+		}
+		object = doc.object(ref.num)
+	}
+	return nil
+}
+
+// object loads an indirect object, or returns nil when it doesn't exist
+func (doc *pdfDocument) object(num int) interface{} {
+	if object, ok := doc.cache[num]; ok {
+		return object
+	}
+	if doc.resolving[num] {
+		return nil
+	}
+	doc.resolving[num] = true
+	defer delete(doc.resolving, num)
+
+	var object interface{}
+	if offset, ok := doc.offsets[num]; ok && offset >= 0 && offset < len(doc.data) {
+		lexer := &pdfLexer{data: doc.data, pos: offset, doc: doc}
+		object, _ = lexer.indirectObject()
+	} else if ref, ok := doc.compressed[num]; ok {
+		object = doc.compressedObject(ref)
+	}
+	doc.cache[num] = object
+	return object
+}
+
+// compressedObject reads an object from an object stream
+func (doc *pdfDocument) compressedObject(ref pdfCompressedRef) interface{} {
+	numbers, offsets, err := doc.objectStreamHeader(ref.stream)
+	if err != nil || ref.index >= len(numbers) {
+		return nil
+	}
+	stream := doc.object(ref.stream).(*pdfStream)
+	data, _ := doc.decodeStream(stream)
+	first, _ := doc.resolve(stream.dict["First"]).(float64)
+	position := int(first) + offsets[ref.index]
+	if first < 0 || position < 0 || position >= len(data) {
+		return nil
+	}
+	lexer := &pdfLexer{data: data, pos: position, doc: doc}
+	return lexer.next()
+}
+
+// objectStreamHeader reads the object numbers and offsets at the start of an object stream
+func (doc *pdfDocument) objectStreamHeader(num int) ([]int, []int, error) {
+	stream, ok := doc.object(num).(*pdfStream)
+	if !ok {
+		return nil, nil, fmt.Errorf("object stream %d not found", num)
+	}
+	data, err := doc.decodeStream(stream)
+	if err != nil {
+		return nil, nil, err
+	}
+	count, _ := doc.resolve(stream.dict["N"]).(float64)
+
+	var numbers, offsets []int
+	lexer := &pdfLexer{data: data}
+	for i := 0; i < int(count); i++ {
+		objectNum, ok1 := lexer.next().(float64)
+		offset, ok2 := lexer.next().(float64)
+		if !ok1 || !ok2 {
+			break
+		}
+		numbers = append(numbers, int(objectNum))
+		offsets = append(offsets, int(offset))
+	}
+	return numbers, offsets, nil
+}
+
+// dict resolves a value expected to be a dictionary, the dictionary of a stream included
+func (doc *pdfDocument) dict(object interface{}) pdfDict {
+	switch value := doc.resolve(object).(type) {
+	case pdfDict:
+		return value
+	case *pdfStream:
+		return value.dict
+	}
+	return nil
+}
+
+func (doc *pdfDocument) array(object interface{}) pdfArray {
+	array, _ := doc.resolve(object).(pdfArray)
+	return array
+}
+
+func (doc *pdfDocument) number(object interface{}) (float64, bool) {
+	number, ok := doc.resolve(object).(float64)
+	return number, ok
+}
+
+// numbers resolves an array of numbers, skipping the other values
+func (doc *pdfDocument) numbers(object interface{}) []float64 {
+	var numbers []float64
+	for _, item := range doc.array(object) {
+		if number, ok := doc.number(item); ok {
+			numbers = append(numbers, number)
+		}
+	}
+	return numbers
+}
+
+// text resolves a text string, as used in the document information and annotations
+func (doc *pdfDocument) text(object interface{}) string {
+	value, _ := doc.resolve(object).(pdfString)
+	return pdfTextString(value)
+}
+
+// decodeStream applies the filters of a stream
+func (doc *pdfDocument) decodeStream(stream *pdfStream) ([]byte, error) {
+	filters := []interface{}{doc.resolve(stream.dict["Filter"])}
+	if array, ok := filters[0].(pdfArray); ok {
+		filters = array
+	}
+	params := []interface{}{doc.resolve(stream.dict["DecodeParms"])}
+	if array, ok := params[0].(pdfArray); ok {
+		params = array
+	}
+
+	data := stream.data
+	for i, filter := range filters {
+		var parms pdfDict
+		if i < len(params) {
+			parms = doc.dict(params[i])
+		}
+
+		var err error
+		switch doc.resolve(filter) {
+		case nil:
+			continue
+		case pdfName("FlateDecode"), pdfName("Fl"):
+			data, err = pdfInflate(data)
+			if err == nil {
+				data, err = doc.unpredict(data, parms)
+			}
+		case pdfName("ASCIIHexDecode"), pdfName("AHx"):
+			data, err = pdfASCIIHex(data)
+		case pdfName("ASCII85Decode"), pdfName("A85"):
+			data, err = pdfASCII85(data)
+		default:
+			err = fmt.Errorf("unsupported filter %v", filter)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// pdfInflate decompresses zlib data, keeping what was read from truncated streams
+func pdfInflate(data []byte) ([]byte, error) {
+	var reader io.ReadCloser
+	reader, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		// Some writers leave out the zlib header
+		reader = flate.NewReader(bytes.NewReader(data))
+	}
+	defer reader.Close()
+
+	out, err := io.ReadAll(reader)
+	if err != nil && len(out) == 0 {
+		return nil, fmt.Errorf("failed to inflate stream: %w", err)
+	}
+	return out, nil
+}
+
+// unpredict reverses the PNG predictors used by cross-reference and object streams
+func (doc *pdfDocument) unpredict(data []byte, parms pdfDict) ([]byte, error) {
+	predictor, _ := doc.number(parms["Predictor"])
+	if predictor < 10 {
+		return data, nil
+	}
+	colors, bits, columns := 1.0, 8.0, 1.0
+	if value, ok := doc.number(parms["Colors"]); ok {
+		colors = value
+	}
+	if value, ok := doc.number(parms["BitsPerComponent"]); ok {
+		bits = value
+	}
+	if value, ok := doc.number(parms["Columns"]); ok {
+		columns = value
+	}
+	if colors < 1 || colors > 32 || bits < 1 || bits > 16 || columns < 1 || colors*bits*columns > float64(8*len(data)) {
+		return nil, fmt.Errorf("invalid predictor parameters")
+	}
+	bpp := int(colors*bits+7) / 8
+	rowSize := int(colors*bits*columns+7) / 8
+
+	var out []byte
+	previous := make([]byte, rowSize)
+	for position := 0; position+1+rowSize <= len(data); position += 1 + rowSize {
+		kind := data[position]
+		row := append([]byte{}, data[position+1:position+1+rowSize]...)
+		for i := range row {
+			var left, upLeft byte
+			if i >= bpp {
+				left = row[i-bpp]
+				upLeft = previous[i-bpp]
+			}
+			up := previous[i]
+			switch kind {
+			case 1:
+				row[i] += left
+			case 2:
+				row[i] += up
+			case 3:
+				row[i] += byte((int(left) + int(up)) / 2)
+			case 4:
+				row[i] += pdfPaeth(left, up, upLeft)
+			}
+		}
+		out = append(out, row...)
+		previous = row
+	}
+	return out, nil
+}
+
+func pdfPaeth(a, b, c byte) byte {
+	distance := func(value int) int {
+		if value < 0 {
+			return -value
+		}
+		return value
+	}
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := distance(p-int(a)), distance(p-int(b)), distance(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	}
+	return c
+}
+
+func pdfASCIIHex(data []byte) ([]byte, error) {
+	var digits []byte
+	for _, c := range data {
+		if c == '>' {
+			break
+		}
+		if isPDFHexDigit(c) {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	_, err := hex.Decode(out, digits)
+	return out, err
+}
+
+func pdfASCII85(data []byte) ([]byte, error) {
+	var out []byte
+	var group [5]byte
+	count := 0
+	flush := func(n int) {
+		var value uint32
+		for i := 0; i < 5; i++ {
+			value = value*85 + uint32(group[i]-'!')
+		}
+		word := []byte{byte(value >> 24), byte(value >> 16), byte(value >> 8), byte(value)}
+		out = append(out, word[:n]...)
+	}
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case c == '~':
+			i = len(data)
+		case c == 'z' && count == 0:
+			out = append(out, 0, 0, 0, 0)
+		case c >= '!' && c <= 'u':
+			group[count] = c
+			count++
+			if count == 5 {
+				flush(4)
+				count = 0
+			}
+		}
+	}
+	if count > 1 {
+		for i := count; i < 5; i++ {
+			group[i] = 'u'
+		}
+		flush(count - 1)
+	}
+	return out, nil
+}
+
+func pdfBigEndian(data []byte) int {
+	value := 0
+	for _, b := range data {
+		value = value<<8 | int(b)
+	}
+	return value
+}
+
+// pdfDocEncoding maps the bytes of PDFDocEncoding that differ from Latin-1
+var pdfDocEncoding = map[byte]rune{
+	0x80: '•', 0x81: '†', 0x82: '‡', 0x83: '…', 0x84: '—', 0x85: '–', 0x86: 'ƒ', 0x87: '⁄',
+	0x88: '‹', 0x89: '›', 0x8a: '−', 0x8b: '‰', 0x8c: '„', 0x8d: '“', 0x8e: '”', 0x8f: '‘',
+	0x90: '’', 0x91: '‚', 0x92: '™', 0x93: 'ﬁ', 0x94: 'ﬂ', 0x95: 'Ł', 0x96: 'Œ', 0x97: 'Š',
+	0x98: 'Ÿ', 0x99: 'Ž', 0x9a: 'ı', 0x9b: 'ł', 0x9c: 'œ', 0x9d: 'š', 0x9e: 'ž', 0xa0: '€',
+}
+
+// pdfTextString decodes a text string, either UTF-16BE with a byte order mark, UTF-8 with one, or PDFDocEncoding
+func pdfTextString(value pdfString) string {
+	switch {
+	case len(value) >= 2 && value[0] == 0xfe && value[1] == 0xff:
+		return pdfUTF16(string(value[2:]))
+	case len(value) >= 3 && value[:3] == "\xef\xbb\xbf":
+		return string(value[3:])
+	}
+	runes := make([]rune, 0, len(value))
+	for i := 0; i < len(value); i++ {
+		if r, ok := pdfDocEncoding[value[i]]; ok {
+			runes = append(runes, r)
+		} else {
+			runes = append(runes, rune(value[i]))
+		}
+	}
+	return string(runes)
+}
+
+func pdfUTF16(value string) string {
+	return string(utf16.Decode(utf16Units(value)))
+}
+
+// pdfLexer reads the objects of a file, an object stream or a content stream
+type pdfLexer struct {
+	data []byte
+	pos  int
+	doc  *pdfDocument
+}
+
+// End markers of arrays and dictionaries
+type pdfDelimiter byte
+
+func isPDFWhitespace(c byte) bool {
+	return c == 0 || c == '\t' || c == '\n' || c == '\f' || c == '\r' || c == ' '
+}
+
+func isPDFDelimiter(c byte) bool {
+	return bytes.IndexByte([]byte("()<>[]{}/%"), c) >= 0
+}
+
+func isPDFHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		if !isPDFWhitespace(c) {
+			return
+		}
+		l.pos++
+	}
+}
+
+// indirectObject reads "num gen obj ... endobj", with the data of streams
+func (l *pdfLexer) indirectObject() (interface{}, error) {
+	l.next()
+	l.next()
+	if keyword, ok := l.next().(pdfKeyword); !ok || keyword != "obj" {
+		return nil, fmt.Errorf("object expected at offset %d", l.pos)
+	}
+	object := l.next()
+
+	dict, ok := object.(pdfDict)
+	if !ok {
+		return object, nil
+	}
+	l.skipSpace()
+	if l.pos >= len(l.data) || !bytes.HasPrefix(l.data[l.pos:], []byte("stream")) {
+		return dict, nil
+	}
+	l.pos += len("stream")
+	if l.pos < len(l.data) && l.data[l.pos] == '\r' {
+		l.pos++
+	}
+	if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+		l.pos++
+	}
+
+	start := l.pos
+	length := -1
+	if l.doc != nil {
+		if value, ok := l.doc.number(dict["Length"]); ok {
+			length = int(value)
+		}
+	} else if value, ok := dict["Length"].(float64); ok {
+		length = int(value)
+	}
+	// Trust the length only when endstream follows it
+	if length < 0 || length > len(l.data)-start || !bytes.HasPrefix(bytes.TrimLeft(l.data[start+length:], "\r\n \t"), []byte("endstream")) {
+		end := bytes.Index(l.data[start:], []byte("endstream"))
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated stream at offset %d", start)
+		}
+		length = len(bytes.TrimRight(l.data[start:start+end], "\r\n"))
+	}
+	l.pos = start + length
+	return &pdfStream{dict: dict, data: l.data[start : start+length]}, nil
+}
+
+// next reads the next object, keyword or delimiter, or returns nil at the end of the data
+func (l *pdfLexer) next() interface{} {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil
+	}
+
+	c := l.data[l.pos]
+	switch {
+	case c == '/':
+		return l.name()
+	case c == '(':
+		return l.literalString()
+	case c == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<':
+		l.pos += 2
+		return l.dictionary()
+	case c == '<':
+		return l.hexString()
+	case c == '>' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '>':
+		l.pos += 2
+		return pdfDelimiter('>')
+	case c == '[':
+		l.pos++
+		return l.array()
+	case c == ']' || c == ')' || c == '>' || c == '{' || c == '}':
+		l.pos++
+		return pdfDelimiter(c)
+	case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		return l.number()
+	}
+
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFWhitespace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	switch word := string(l.data[start:l.pos]); word {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	default:
+		return pdfKeyword(word)
+	}
+}
+
+func (l *pdfLexer) name() pdfName {
+	l.pos++
+	var b []byte
+	for l.pos < len(l.data) && !isPDFWhitespace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+		c := l.data[l.pos]
+		if c == '#' && l.pos+2 < len(l.data) && isPDFHexDigit(l.data[l.pos+1]) && isPDFHexDigit(l.data[l.pos+2]) {
+			value, _ := strconv.ParseUint(string(l.data[l.pos+1:l.pos+3]), 16, 8)
+			b = append(b, byte(value))
+			l.pos += 3
+			continue
+		}
+		b = append(b, c)
+		l.pos++
+	}
+	return pdfName(b)
+}
+
+// number reads a number, or a reference when it is followed by a generation number and R
+func (l *pdfLexer) number() interface{} {
+	start := l.pos
+	l.pos++
+	for l.pos < len(l.data) && (l.data[l.pos] == '.' || (l.data[l.pos] >= '0' && l.data[l.pos] <= '9')) {
+		l.pos++
+	}
+	value, err := strconv.ParseFloat(string(l.data[start:l.pos]), 64)
+	if err != nil {
+		return float64(0)
+	}
+	if value != float64(int(value)) || value < 0 {
+		return value
+	}
+
+	// Look ahead for "gen R"
+	saved := l.pos
+	l.skipSpace()
+	genStart := l.pos
+	for l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '9' {
+		l.pos++
+	}
+	if l.pos > genStart {
+		gen, _ := strconv.Atoi(string(l.data[genStart:l.pos]))
+		l.skipSpace()
+		if l.pos < len(l.data) && l.data[l.pos] == 'R' && (l.pos+1 == len(l.data) || isPDFWhitespace(l.data[l.pos+1]) || isPDFDelimiter(l.data[l.pos+1])) {
+			l.pos++
+			return pdfRef{num: int(value), gen: gen}
+		}
+	}
+	l.pos = saved
+	return value
+}
+
+func (l *pdfLexer) literalString() pdfString {
+	l.pos++
+	var b []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return pdfString(b)
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				continue
+			}
+			escaped := l.data[l.pos]
+			l.pos++
+			switch escaped {
+			case 'n':
+				b = append(b, '\n')
+			case 'r':
+				b = append(b, '\r')
+			case 't':
+				b = append(b, '\t')
+			case 'b':
+				b = append(b, '\b')
+			case 'f':
+				b = append(b, '\f')
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+			case '\n':
+			default:
+				if escaped >= '0' && escaped <= '7' {
+					value := int(escaped - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						value = value*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					b = append(b, byte(value))
+				} else {
+					b = append(b, escaped)
+				}
+			}
+			continue
+		}
+		b = append(b, c)
+	}
+	return pdfString(b)
+}
+
+func (l *pdfLexer) hexString() pdfString {
+	l.pos++
+	end := bytes.IndexByte(l.data[l.pos:], '>')
+	if end < 0 {
+		end = len(l.data) - l.pos
+	}
+	decoded, _ := pdfASCIIHex(l.data[l.pos : l.pos+end])
+	l.pos = min(l.pos+end+1, len(l.data))
+	return pdfString(decoded)
+}
+
+func (l *pdfLexer) array() pdfArray {
+	array := pdfArray{}
+	for l.pos < len(l.data) {
+		item := l.next()
+		if item == pdfDelimiter(']') {
+			return array
+		}
+		if _, ok := item.(pdfDelimiter); ok {
+			continue
+		}
+		array = append(array, item)
+	}
+	return array
+}
+
+func (l *pdfLexer) dictionary() pdfDict {
+	dict := pdfDict{}
+	for l.pos < len(l.data) {
+		key := l.next()
+		if key == pdfDelimiter('>') {
+			return dict
+		}
+		name, ok := key.(pdfName)
+		if !ok {
+			continue
+		}
+		value := l.next()
+		if value == pdfDelimiter('>') {
+			return dict
+		}
+		dict[name] = value
+	}
+	return dict
+}
@@ -0,0 +1,377 @@
+package bookmarks
+
+import (
+	"bytes"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// pdfGlyph is a character drawn on a page, in default user space
+type pdfGlyph struct {
+	text string
+	// origin and end of the glyph on the baseline, and its center
+	x0, y0, x1, y1 float64
+	cx, cy         float64
+	size           float64
+}
+
+// pdfMatrix is a transformation matrix [a b c d e f]
+type pdfMatrix [6]float64
+
+var pdfIdentity = pdfMatrix{1, 0, 0, 1, 0, 0}
+
+// multiply returns m × n
+func (m pdfMatrix) multiply(n pdfMatrix) pdfMatrix {
+	return pdfMatrix{
+		m[0]*n[0] + m[1]*n[2],
+		m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2],
+		m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4],
+		m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+func (m pdfMatrix) apply(x, y float64) (float64, float64) {
+	return x*m[0] + y*m[2] + m[4], x*m[1] + y*m[3] + m[5]
+}
+
+// pdfGraphicsState holds the parts of the graphics state that position text
+type pdfGraphicsState struct {
+	ctm         pdfMatrix
+	font        *pdfFont
+	fontSize    float64
+	charSpacing float64
+	wordSpacing float64
+	scale       float64
+	leading     float64
+	rise        float64
+}
+
+// pdfTextExtractor runs the text operators of content streams and records the glyphs they draw
+type pdfTextExtractor struct {
+	doc    *pdfDocument
+	fonts  map[pdfRef]*pdfFont
+	glyphs []pdfGlyph
+}
+
+func newPDFTextExtractor(doc *pdfDocument) *pdfTextExtractor {
+	return &pdfTextExtractor{doc: doc, fonts: make(map[pdfRef]*pdfFont)}
+}
+
+// maxFormDepth limits the nesting of form XObjects
+const maxFormDepth = 8
+
+// pageGlyphs returns the glyphs drawn by a page
+func (e *pdfTextExtractor) pageGlyphs(page pdfDict, resources pdfDict) []pdfGlyph {
+	e.glyphs = nil
+	// The contents are a stream or an array of streams, split anywhere
+	var content []byte
+	items, ok := e.doc.resolve(page["Contents"]).(pdfArray)
+	if !ok {
+		items = pdfArray{page["Contents"]}
+	}
+	for _, item := range items {
+		if stream, ok := e.doc.resolve(item).(*pdfStream); ok {
+			if data, err := e.doc.decodeStream(stream); err == nil {
+				content = append(append(content, data...), '\n')
+			}
+		}
+	}
+
+	state := pdfGraphicsState{ctm: pdfIdentity, scale: 1}
+	e.run(content, resources, state, 0)
+	return e.glyphs
+}
+
+// run interprets a content stream
+func (e *pdfTextExtractor) run(content []byte, resources pdfDict, state pdfGraphicsState, depth int) {
+	lexer := &pdfLexer{data: content}
+	var stack []pdfGraphicsState
+	var operands []interface{}
+	textMatrix, lineMatrix := pdfIdentity, pdfIdentity
+
+	number := func(i int) float64 {
+		if i < len(operands) {
+			if value, ok := operands[i].(float64); ok {
+				return value
+			}
+		}
+		return 0
+	}
+	matrix := func() pdfMatrix {
+		var m pdfMatrix
+		for i := range m {
+			m[i] = number(i)
+		}
+		return m
+	}
+	moveLine := func(tx, ty float64) {
+		lineMatrix = pdfMatrix{1, 0, 0, 1, tx, ty}.multiply(lineMatrix)
+		textMatrix = lineMatrix
+	}
+
+	for lexer.pos < len(lexer.data) {
+		token := lexer.next()
+		if token == nil && lexer.pos >= len(lexer.data) {
+			break
+		}
+		operator, ok := token.(pdfKeyword)
+		if !ok {
+			if _, delimiter := token.(pdfDelimiter); !delimiter {
+				operands = append(operands, token)
+			}
+			continue
+		}
+
+		switch operator {
+		case "q":
+			stack = append(stack, state)
+		case "Q":
+			if len(stack) > 0 {
+				state = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+		case "cm":
+			state.ctm = matrix().multiply(state.ctm)
+		case "BT":
+			textMatrix, lineMatrix = pdfIdentity, pdfIdentity
+		case "Tf":
+			if len(operands) == 2 {
+				name, _ := operands[0].(pdfName)
+				state.font = e.font(e.doc.dict(resources["Font"])[name])
+				state.fontSize = number(1)
+			}
+		case "Tc":
+			state.charSpacing = number(0)
+		case "Tw":
+			state.wordSpacing = number(0)
+		case "Tz":
+			state.scale = number(0) / 100
+		case "TL":
+			state.leading = number(0)
+		case "Ts":
+			state.rise = number(0)
+		case "Td":
+			moveLine(number(0), number(1))
+		case "TD":
+			state.leading = -number(1)
+			moveLine(number(0), number(1))
+		case "Tm":
+			textMatrix = matrix()
+			lineMatrix = textMatrix
+		case "T*":
+			moveLine(0, -state.leading)
+		case "Tj", "'", "\"":
+			if operator == "\"" && len(operands) == 3 {
+				state.wordSpacing = number(0)
+				state.charSpacing = number(1)
+				operands = operands[2:]
+			}
+			if operator != "Tj" {
+				moveLine(0, -state.leading)
+			}
+			if len(operands) > 0 {
+				if text, ok := operands[len(operands)-1].(pdfString); ok {
+					textMatrix = e.show(text, state, textMatrix)
+				}
+			}
+		case "TJ":
+			if len(operands) > 0 {
+				array, _ := operands[len(operands)-1].(pdfArray)
+				for _, item := range array {
+					switch value := item.(type) {
+					case pdfString:
+						textMatrix = e.show(value, state, textMatrix)
+					case float64:
+						tx := -value / 1000 * state.fontSize * state.scale
+						textMatrix = pdfMatrix{1, 0, 0, 1, tx, 0}.multiply(textMatrix)
+					}
+				}
+			}
+		case "Do":
+			if len(operands) == 1 && depth < maxFormDepth {
+				name, _ := operands[0].(pdfName)
+				e.form(e.doc.dict(resources["XObject"])[name], resources, state, depth)
+			}
+		case "BI":
+			// Skip inline images, their data is binary and ends with EI between whitespace
+			lexer.pos = skipInlineImage(lexer.data, lexer.pos)
+		}
+		operands = operands[:0]
+	}
+}
+
+// skipInlineImage returns the position after the end of the inline image starting at pos
+func skipInlineImage(data []byte, pos int) int {
+	for {
+		end := bytes.Index(data[pos:], []byte("EI"))
+		if end < 0 {
+			return len(data)
+		}
+		end += pos
+		pos = end + 2
+		if end > 0 && isPDFWhitespace(data[end-1]) && (pos == len(data) || isPDFWhitespace(data[pos])) {
+			return pos
+		}
+	}
+}
+
+// form runs the content of a form XObject
+func (e *pdfTextExtractor) form(object interface{}, resources pdfDict, state pdfGraphicsState, depth int) {
+	stream, ok := e.doc.resolve(object).(*pdfStream)
+	if !ok || stream.dict["Subtype"] != pdfName("Form") {
+		return
+	}
+	data, err := e.doc.decodeStream(stream)
+	if err != nil {
+		return
+	}
+	if values := e.doc.numbers(stream.dict["Matrix"]); len(values) == 6 {
+		var m pdfMatrix
+		copy(m[:], values)
+		state.ctm = m.multiply(state.ctm)
+	}
+	if formResources := e.doc.dict(stream.dict["Resources"]); formResources != nil {
+		resources = formResources
+	}
+	e.run(data, resources, state, depth+1)
+}
+
+// show records the glyphs of a string and returns the text matrix after them
+func (e *pdfTextExtractor) show(text pdfString, state pdfGraphicsState, textMatrix pdfMatrix) pdfMatrix {
+	font := state.font
+	if font == nil {
+		font = &pdfFont{defaultWidth: 500, widthScale: 0.001}
+	}
+
+	for _, code := range font.codes(string(text)) {
+		width := font.width(code.code)
+		trm := pdfMatrix{state.fontSize * state.scale, 0, 0, state.fontSize, 0, state.rise}.multiply(textMatrix).multiply(state.ctm)
+
+		x0, y0 := trm.apply(0, 0)
+		x1, y1 := trm.apply(width, 0)
+		cx, cy := trm.apply(width/2, 0.3)
+		ux, uy := trm.apply(0, 1)
+		e.glyphs = append(e.glyphs, pdfGlyph{
+			text: font.text(code),
+			x0:   x0, y0: y0, x1: x1, y1: y1,
+			cx: cx, cy: cy,
+			size: math.Hypot(ux-x0, uy-y0),
+		})
+
+		advance := width*state.fontSize + state.charSpacing
+		if code.length == 1 && code.code == 32 {
+			advance += state.wordSpacing
+		}
+		textMatrix = pdfMatrix{1, 0, 0, 1, advance * state.scale, 0}.multiply(textMatrix)
+	}
+	return textMatrix
+}
+
+// font loads a font resource, fonts referenced indirectly are cached
+func (e *pdfTextExtractor) font(object interface{}) *pdfFont {
+	if ref, ok := object.(pdfRef); ok {
+		if font, ok := e.fonts[ref]; ok {
+			return font
+		}
+		font := newPDFFont(e.doc, e.doc.dict(ref))
+		e.fonts[ref] = font
+		return font
+	}
+	if dict := e.doc.dict(object); dict != nil {
+		return newPDFFont(e.doc, dict)
+	}
+	return nil
+}
+
+// textInQuads returns the text of the glyphs inside the given QuadPoints, line by line
+func textInQuads(glyphs []pdfGlyph, quadPoints []float64) string {
+	used := make([]bool, len(glyphs))
+	var lines []string
+	for q := 0; q+8 <= len(quadPoints); q += 8 {
+		quad := quadPoints[q : q+8]
+		// Order the glyphs along the top edge of the quad, the reading direction
+		dx, dy := quad[2]-quad[0], quad[3]-quad[1]
+		if dx == 0 && dy == 0 {
+			dx = 1
+		}
+
+		type candidate struct {
+			index int
+			along float64
+		}
+		var inside []candidate
+		for i, glyph := range glyphs {
+			if used[i] || !quadContains(quad, glyph.cx, glyph.cy) {
+				continue
+			}
+			used[i] = true
+			inside = append(inside, candidate{index: i, along: (glyph.cx-quad[0])*dx + (glyph.cy-quad[1])*dy})
+		}
+		sort.SliceStable(inside, func(i, j int) bool { return inside[i].along < inside[j].along })
+
+		var line strings.Builder
+		var previous *pdfGlyph
+		for _, c := range inside {
+			glyph := &glyphs[c.index]
+			// A gap wider than 15% of the font size is a missing space
+			if previous != nil && math.Hypot(glyph.x0-previous.x1, glyph.y0-previous.y1) > 0.15*glyph.size {
+				line.WriteByte(' ')
+			}
+			line.WriteString(glyph.text)
+			previous = glyph
+		}
+		if text := strings.TrimSpace(line.String()); text != "" {
+			lines = append(lines, text)
+		}
+	}
+	return joinPDFLines(lines)
+}
+
+// joinPDFLines joins the lines of a highlight, removing the hyphens of words split across lines
+func joinPDFLines(lines []string) string {
+	var b strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			previous := lines[i-1]
+			last, _ := utf8.DecodeLastRuneInString(strings.TrimSuffix(previous, "-"))
+			first, _ := utf8.DecodeRuneInString(line)
+			if strings.HasSuffix(previous, "-") && isLower(last) && isLower(first) {
+				current := b.String()
+				b.Reset()
+				b.WriteString(strings.TrimSuffix(current, "-"))
+			} else {
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(line)
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+func isLower(r rune) bool {
+	return strings.ToLower(string(r)) == string(r) && strings.ToUpper(string(r)) != string(r)
+}
+
+// quadContains tests whether a point is inside a quadrilateral. Writers don't agree on the order
+// of the points, so both the documented order and the clockwise one are tried.
+func quadContains(quad []float64, x, y float64) bool {
+	documented := []float64{quad[0], quad[1], quad[2], quad[3], quad[6], quad[7], quad[4], quad[5]}
+	return polygonContains(documented, x, y) || polygonContains(quad, x, y)
+}
+
+func polygonContains(points []float64, x, y float64) bool {
+	inside := false
+	n := len(points) / 2
+	for i, j := 0, n-1; i < n; j, i = i, i+1 {
+		xi, yi := points[2*i], points[2*i+1]
+		xj, yj := points[2*j], points[2*j+1]
+		if (yi > y) != (yj > y) && x < (xj-xi)*(y-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
@@ -2,9 +2,11 @@
 package bookmarks
 
 import (
+	"anytype-readwise/core"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -13,11 +15,14 @@ type ReadwiseClient struct {
 	token      string
 	baseURL    string
 	httpClient *http.Client
+	books      []ReadwiseBook
+	highlights map[int][]Highlight
 }
 
 type ReadwiseBook struct {
 	ID            int         `json:"id"`
 	Title         string      `json:"title"`
+	ReadableTitle string      `json:"readable_title"`
 	Author        string      `json:"author"`
 	Category      string      `json:"category"`
 	Source        string      `json:"source"`
@@ -25,33 +30,114 @@ type ReadwiseBook struct {
 	LastHighlight time.Time   `json:"last_highlight_at"`
 	Updated       time.Time   `json:"updated"`
 	CoverImageURL string      `json:"cover_image_url"`
+	HighlightsURL string      `json:"highlights_url"`
+	SourceURL     string      `json:"source_url"`
+	UniqueURL     string      `json:"unique_url"`
+	ReadwiseURL   string      `json:"readwise_url"`
+	ASIN          string      `json:"asin"`
+	ISBN          string      `json:"isbn,omitempty"`
+	DocumentNote  string      `json:"document_note"`
+	Summary       string      `json:"summary"`
+	Tags          []Tag       `json:"tags"`
+	BookTags      []Tag       `json:"book_tags"`
+	Language      string      `json:"language,omitempty"`
+	DOI           string      `json:"doi,omitempty"`
+	CitationKey   string      `json:"citation_key,omitempty"`
+	Year          int         `json:"year,omitempty"`
 	Highlights    []Highlight `json:"highlights,omitempty"`
 }
 
+type Tag struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
 type Highlight struct {
 	ID            int       `json:"id"`
+	BookID        int       `json:"book_id"`
 	Text          string    `json:"text"`
 	Note          string    `json:"note"`
 	Location      int       `json:"location"`
+	EndLocation   int       `json:"end_location"`
 	LocationType  string    `json:"location_type"`
 	HighlightedAt time.Time `json:"highlighted_at"`
+	CreatedAt     time.Time `json:"created_at"`
 	URL           string    `json:"url"`
+	HighlightURL  string    `json:"highlight_url"`
+	ReadwiseURL   string    `json:"readwise_url"`
+	ExternalID    string    `json:"external_id"`
 	Color         string    `json:"color"`
 	Updated       time.Time `json:"updated"`
+	IsFavorite    bool      `json:"is_favorite"`
+	IsDiscard     bool      `json:"is_discard"`
+	IsDeleted     bool      `json:"is_deleted"`
+	Tags          []Tag     `json:"tags"`
+	Chapter       string    `json:"chapter,omitempty"`
+	PageLabel     string    `json:"page_label,omitempty"`
+	// Origin is the source a highlight was read from when several sources are merged
+	Origin string `json:"origin,omitempty"`
+
+	// HeadingLevel is set when the note turned the highlight into a heading (.h1 to .h6)
+	HeadingLevel int `json:"-"`
+	// MergedIDs are the highlights concatenated into this one (.c1, .c2...)
+	MergedIDs []int `json:"-"`
+}
+
+// DisplayTitle returns the readable title when Readwise provides one
+func (b ReadwiseBook) DisplayTitle() string {
+	if b.ReadableTitle != "" {
+		return b.ReadableTitle
+	}
+	return b.Title
+}
+
+// DefaultName returns the object name used when no name pattern is configured
+func (b ReadwiseBook) DefaultName() string {
+	return fmt.Sprintf("%s - %s [SYNC]", b.Title, b.Author)
+}
+
+// AllTags returns the book tags, whether they came as tags or book_tags
+func (b ReadwiseBook) AllTags() []Tag {
+	if len(b.BookTags) == 0 {
+		return b.Tags
+	}
+
+	tags := append([]Tag{}, b.Tags...)
+	for _, bookTag := range b.BookTags {
+		duplicate := false
+		for _, tag := range b.Tags {
+			if tag.ID == bookTag.ID && tag.Name == bookTag.Name {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			tags = append(tags, bookTag)
+		}
+	}
+	return tags
+}
+
+// readwiseExportResponse is a page of the export endpoint, books come with their highlights
+type readwiseExportResponse struct {
+	Count int `json:"count"`
+	// NextPageCursor is a number, or null on the last page
+	NextPageCursor json.RawMessage      `json:"nextPageCursor"`
+	Results        []readwiseExportBook `json:"results"`
 }
 
-type ReadwiseBooksResponse struct {
-	Count    int            `json:"count"`
-	Next     string         `json:"next"`
-	Previous string         `json:"previous"`
-	Results  []ReadwiseBook `json:"results"`
+// readwiseExportBook is a book as returned by the export endpoint
+type readwiseExportBook struct {
+	ReadwiseBook
+	UserBookID int                       `json:"user_book_id"`
+	IsDeleted  bool                      `json:"is_deleted"`
+	Highlights []readwiseExportHighlight `json:"highlights"`
 }
 
-type ReadwiseHighlightsResponse struct {
-	Count    int         `json:"count"`
-	Next     string      `json:"next"`
-	Previous string      `json:"previous"`
-	Results  []Highlight `json:"results"`
+// readwiseExportHighlight is a highlight as returned by the export endpoint
+type readwiseExportHighlight struct {
+	Highlight
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 func NewReadwiseClient(token string) *ReadwiseClient {
@@ -76,66 +162,101 @@ func (c *ReadwiseClient) makeRequest(endpoint string) (*http.Response, error) {
 	return c.httpClient.Do(req)
 }
 
+// GetBooks returns every book of the library, read with its highlights from the export endpoint.
+// Unlike the books and highlights endpoints, the export has the readable titles, book tags,
+// Readwise URLs and the favorite, discard and deleted flags.
 func (c *ReadwiseClient) GetBooks() ([]ReadwiseBook, error) {
-	var allBooks []ReadwiseBook
-	url := "/books/"
+	if c.highlights != nil {
+		return c.books, nil
+	}
 
+	var books []ReadwiseBook
+	highlights := make(map[int][]Highlight)
+	url := "/export/"
 	for url != "" {
 		resp, err := c.makeRequest(url)
 		if err != nil {
 			return nil, fmt.Errorf("failed to fetch books: %w", err)
 		}
-		defer resp.Body.Close()
 
 		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("readwise API returned status %d", resp.StatusCode)
+			err := decodeReadwiseError(resp)
+			resp.Body.Close()
+			return nil, err
 		}
 
-		var booksResp ReadwiseBooksResponse
-		if err := json.NewDecoder(resp.Body).Decode(&booksResp); err != nil {
-			return nil, fmt.Errorf("failed to decode books response: %w", err)
+		var exportResp readwiseExportResponse
+		err = json.NewDecoder(resp.Body).Decode(&exportResp)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode export response: %w", err)
 		}
 
-		allBooks = append(allBooks, booksResp.Results...)
+		for _, result := range exportResp.Results {
+			if result.IsDeleted {
+				continue
+			}
+			book := result.exportedBook()
+			books = append(books, book)
+			highlights[book.ID] = result.exportedHighlights()
+		}
 
-		if booksResp.Next != "" {
-			url = booksResp.Next[len(c.baseURL):]
-		} else {
-			url = ""
+		url = ""
+		if cursor := strings.Trim(string(exportResp.NextPageCursor), `"`); cursor != "" && cursor != "null" {
+			url = "/export/?pageCursor=" + cursor
 		}
 	}
 
-	return allBooks, nil
+	c.books = books
+	c.highlights = highlights
+	return books, nil
 }
 
+// GetHighlights returns the highlights of a book, read along with the books
 func (c *ReadwiseClient) GetHighlights(bookID int) ([]Highlight, error) {
-	var allHighlights []Highlight
-	url := fmt.Sprintf("/highlights/?book_id=%d", bookID)
+	if _, err := c.GetBooks(); err != nil {
+		return nil, err
+	}
+	highlights, ok := c.highlights[bookID]
+	if !ok {
+		return nil, fmt.Errorf("%w: book %d", core.ErrNotFound, bookID)
+	}
+	return highlights, nil
+}
 
-	for url != "" {
-		resp, err := c.makeRequest(url)
-		if err != nil {
-			return nil, fmt.Errorf("failed to fetch highlights: %w", err)
+// exportedBook fills in the fields the export leaves out, from the highlights of the book
+func (b readwiseExportBook) exportedBook() ReadwiseBook {
+	book := b.ReadwiseBook
+	book.ID = b.UserBookID
+	book.Highlights = nil
+	if book.HighlightsURL == "" {
+		book.HighlightsURL = book.ReadwiseURL
+	}
+	for _, highlight := range b.Highlights {
+		if highlight.IsDeleted {
+			continue
 		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("readwise API returned status %d", resp.StatusCode)
+		book.NumHighlights++
+		if highlight.HighlightedAt.After(book.LastHighlight) {
+			book.LastHighlight = highlight.HighlightedAt
 		}
-
-		var highlightsResp ReadwiseHighlightsResponse
-		if err := json.NewDecoder(resp.Body).Decode(&highlightsResp); err != nil {
-			return nil, fmt.Errorf("failed to decode highlights response: %w", err)
+		if highlight.UpdatedAt.After(book.Updated) {
+			book.Updated = highlight.UpdatedAt
 		}
+	}
+	return book
+}
 
-		allHighlights = append(allHighlights, highlightsResp.Results...)
-
-		if highlightsResp.Next != "" {
-			url = highlightsResp.Next[len(c.baseURL):]
-		} else {
-			url = ""
+// exportedHighlights returns the highlights of a book, deleted ones included so they are detected as removed
+func (b readwiseExportBook) exportedHighlights() []Highlight {
+	highlights := make([]Highlight, 0, len(b.Highlights))
+	for _, exported := range b.Highlights {
+		highlight := exported.Highlight
+		highlight.BookID = b.UserBookID
+		if highlight.Updated.IsZero() {
+			highlight.Updated = exported.UpdatedAt
 		}
+		highlights = append(highlights, highlight)
 	}
-
-	return allHighlights, nil
+	return highlights
 }
@@ -0,0 +1,100 @@
+package bookmarks
+
+import (
+	"anytype-readwise/core"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const exportPage1 = `{
+  "count": 2,
+  "nextPageCursor": 12345,
+  "results": [
+    {
+      "user_book_id": 1,
+      "title": "deep-work.epub",
+      "readable_title": "Deep Work",
+      "author": "Cal Newport",
+      "category": "books",
+      "unique_url": "https://example.com/deep-work",
+      "readwise_url": "https://readwise.io/bookreview/1",
+      "book_tags": [{"id": 7, "name": "focus"}],
+      "highlights": [
+        {"id": 10, "text": "Kept", "note": "n", "end_location": 12, "external_id": "ext-10", "is_favorite": true,
+         "readwise_url": "https://readwise.io/open/10", "highlighted_at": "2024-01-02T00:00:00Z", "updated_at": "2024-03-01T00:00:00Z"},
+        {"id": 11, "text": "Discarded", "is_discard": true, "highlighted_at": "2024-01-01T00:00:00Z", "updated_at": "2024-01-01T00:00:00Z"},
+        {"id": 12, "text": "Deleted", "is_deleted": true, "highlighted_at": "2024-05-01T00:00:00Z", "updated_at": "2024-05-01T00:00:00Z"}
+      ]
+    },
+    {"user_book_id": 2, "title": "Gone", "is_deleted": true, "highlights": []}
+  ]
+}`
+
+const exportPage2 = `{
+  "count": 1,
+  "nextPageCursor": null,
+  "results": [{"user_book_id": 3, "title": "Article", "category": "articles", "highlights": []}]
+}`
+
+func TestReadwiseClientExport(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/export/" || r.Header.Get("Authorization") != "Token secret" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		if r.URL.Query().Get("pageCursor") == "12345" {
+			w.Write([]byte(exportPage2))
+			return
+		}
+		w.Write([]byte(exportPage1))
+	}))
+	defer server.Close()
+
+	client := NewReadwiseClient("secret")
+	client.baseURL = server.URL
+
+	books, err := client.GetBooks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(books) != 2 || books[0].ID != 1 || books[1].ID != 3 {
+		t.Fatalf("got books %+v, want the books 1 and 3", books)
+	}
+
+	book := books[0]
+	if book.DisplayTitle() != "Deep Work" || book.UniqueURL != "https://example.com/deep-work" || book.HighlightsURL != book.ReadwiseURL {
+		t.Errorf("book fields not decoded: %+v", book)
+	}
+	if len(book.AllTags()) != 1 || book.AllTags()[0].Name != "focus" {
+		t.Errorf("got tags %+v, want focus", book.AllTags())
+	}
+	if book.NumHighlights != 2 || !book.LastHighlight.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)) || !book.Updated.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("got %d highlights, last at %v, updated %v", book.NumHighlights, book.LastHighlight, book.Updated)
+	}
+
+	highlights, err := client.GetHighlights(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(highlights) != 3 {
+		t.Fatalf("got %d highlights, want 3", len(highlights))
+	}
+	kept := highlights[0]
+	if kept.BookID != 1 || kept.EndLocation != 12 || kept.ExternalID != "ext-10" || !kept.IsFavorite || kept.ReadwiseURL == "" || kept.Updated.IsZero() {
+		t.Errorf("highlight fields not decoded: %+v", kept)
+	}
+	if !highlights[1].IsDiscard || !highlights[2].IsDeleted {
+		t.Errorf("discard and deleted flags not decoded: %+v", highlights[1:])
+	}
+	if requests != 2 {
+		t.Errorf("got %d requests, want the 2 export pages once", requests)
+	}
+
+	if _, err := client.GetHighlights(2); !errors.Is(err, core.ErrNotFound) {
+		t.Errorf("got error %v for a deleted book, want ErrNotFound", err)
+	}
+}
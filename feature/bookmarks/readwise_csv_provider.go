@@ -0,0 +1,181 @@
+package bookmarks
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReadwiseCSVProvider implements the BookmarksProvider interface by reading a Readwise CSV export,
+// so that a full backfill can run offline instead of through the rate-limited API
+type ReadwiseCSVProvider struct {
+	path string
+	localLibrary
+}
+
+// NewReadwiseCSVProvider creates a new ReadwiseCSVProvider for the given export file
+func NewReadwiseCSVProvider(path string) *ReadwiseCSVProvider {
+	p := &ReadwiseCSVProvider{path: path}
+	p.load = p.parse
+	return p
+}
+
+// Columns of the Readwise export
+const (
+	csvHighlight     = "highlight"
+	csvBookTitle     = "book title"
+	csvBookAuthor    = "book author"
+	csvAmazonBookID  = "amazon book id"
+	csvNote          = "note"
+	csvColor         = "color"
+	csvTags          = "tags"
+	csvLocationType  = "location type"
+	csvLocation      = "location"
+	csvHighlightedAt = "highlighted at"
+	csvDocumentTags  = "document tags"
+)
+
+var csvDateLayouts = []string{
+	"2006-01-02 15:04:05-07:00",
+	"2006-01-02 15:04:05.999999-07:00",
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+	"January 2, 2006 3:04 PM",
+	"2006-01-02",
+}
+
+func (p *ReadwiseCSVProvider) parse() (*libraryBuilder, error) {
+	file, err := os.Open(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CSV export: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.TrimPrefix(name, "\ufeff")
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{csvHighlight, csvBookTitle} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("CSV export has no %q column", required)
+		}
+	}
+
+	builder := newLibraryBuilder()
+	seen := make(map[int]int)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV export: %w", err)
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		if field(csvHighlight) == "" {
+			continue
+		}
+
+		// The Amazon ID identifies Kindle books across title changes, other books are matched by title and author
+		key := field(csvAmazonBookID)
+		if key == "" {
+			key = normalizeKey(field(csvBookTitle)) + "\x00" + normalizeKey(field(csvBookAuthor))
+		}
+		book := builder.addBook(ReadwiseBook{
+			ID:       syntheticID("readwise-csv", key),
+			Title:    field(csvBookTitle),
+			Author:   field(csvBookAuthor),
+			Category: csvCategory(field(csvBookTitle), field(csvAmazonBookID), field(csvLocationType)),
+			Source:   "readwise-csv",
+			ASIN:     field(csvAmazonBookID),
+			Tags:     csvTagList(field(csvDocumentTags)),
+		})
+
+		highlight := csvHighlightFrom(key, field)
+		// The same passage can be highlighted twice at the same location
+		id := highlight.ID
+		if count := seen[id]; count > 0 {
+			highlight.ID = syntheticID("readwise-csv", strconv.Itoa(id), strconv.Itoa(count))
+		}
+		seen[id]++
+		builder.addHighlight(book.ID, highlight)
+	}
+
+	return builder, nil
+}
+
+// csvCategory guesses the category of a book, the export has none: tweets are grouped
+// into "Tweets From" books, podcasts are located by time and web articles by offset
+func csvCategory(title, asin, locationType string) string {
+	switch {
+	case asin != "":
+		return "books"
+	case strings.HasPrefix(title, "Tweets From "):
+		return "tweets"
+	case locationType == "time_offset":
+		return "podcasts"
+	case locationType == "offset":
+		return "articles"
+	}
+	return "books"
+}
+
+func csvHighlightFrom(bookKey string, field func(string) string) Highlight {
+	text := field(csvHighlight)
+	highlightedAt := parseCSVDate(field(csvHighlightedAt))
+	location, _ := strconv.Atoi(field(csvLocation))
+
+	return Highlight{
+		ID:            syntheticID("readwise-csv", bookKey, field(csvLocationType), field(csvLocation), normalizeKey(text)),
+		Text:          text,
+		Note:          field(csvNote),
+		Color:         field(csvColor),
+		Location:      location,
+		EndLocation:   location,
+		LocationType:  field(csvLocationType),
+		HighlightedAt: highlightedAt,
+		CreatedAt:     highlightedAt,
+		Updated:       highlightedAt,
+		Tags:          csvTagList(field(csvTags)),
+	}
+}
+
+// csvTagList splits a comma separated list of tags. The export has no tag IDs.
+func csvTagList(value string) []Tag {
+	var tags []Tag
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			tags = append(tags, Tag{Name: name})
+		}
+	}
+	return tags
+}
+
+func parseCSVDate(value string) time.Time {
+	for _, layout := range csvDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date
+		}
+	}
+	return time.Time{}
+}
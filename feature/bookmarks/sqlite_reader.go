@@ -0,0 +1,486 @@
+package bookmarks
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"unicode/utf16"
+)
+
+// sqliteDatabase is a minimal read-only reader of SQLite database files. It only walks
+// table b-trees, which is enough to read whole tables without a cgo driver.
+type sqliteDatabase struct {
+	data     []byte
+	pageSize int
+	usable   int
+	encoding uint32
+}
+
+// sqliteRow is a table row keyed by lowercased column name. Values are nil, int64, float64, string or []byte.
+type sqliteRow map[string]interface{}
+
+const sqliteHeader = "SQLite format 3\x00"
+
+// SQLite text encodings
+const (
+	sqliteUTF8    = 1
+	sqliteUTF16LE = 2
+	sqliteUTF16BE = 3
+)
+
+// B-tree page types
+const (
+	sqliteTableInterior = 0x05
+	sqliteTableLeaf     = 0x0d
+)
+
+// openSQLite reads a database file in memory
+func openSQLite(path string) (*sqliteDatabase, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read database: %w", err)
+	}
+	db, err := newSQLiteDatabase(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	return db, nil
+}
+
+// newSQLiteDatabase checks the header of a database read in memory
+func newSQLiteDatabase(data []byte) (*sqliteDatabase, error) {
+	if len(data) < 100 || string(data[:16]) != sqliteHeader {
+		return nil, fmt.Errorf("not a SQLite database")
+	}
+
+	pageSize := int(binary.BigEndian.Uint16(data[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	if pageSize < 512 || len(data)%pageSize != 0 {
+		return nil, fmt.Errorf("invalid page size")
+	}
+
+	db := &sqliteDatabase{
+		data:     data,
+		pageSize: pageSize,
+		usable:   pageSize - int(data[20]),
+		encoding: binary.BigEndian.Uint32(data[56:60]),
+	}
+	// The file format requires at least 480 usable bytes per page
+	if db.usable < 480 {
+		return nil, fmt.Errorf("invalid reserved space")
+	}
+	if db.encoding == 0 {
+		db.encoding = sqliteUTF8
+	}
+	return db, nil
+}
+
+// readTable returns all the rows of a table, in rowid order
+func (db *sqliteDatabase) readTable(name string) ([]sqliteRow, error) {
+	rootPage, columns, err := db.findTable(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []sqliteRow
+	err = db.walkTable(rootPage, func(rowid int64, payload []byte) error {
+		values, err := db.decodeRecord(payload)
+		if err != nil {
+			return err
+		}
+		row := make(sqliteRow, len(columns))
+		for i, column := range columns {
+			if i < len(values) {
+				row[column.name] = values[i]
+			}
+			// An INTEGER PRIMARY KEY column is an alias of the rowid and is stored as NULL
+			if column.rowid {
+				row[column.name] = rowid
+			}
+		}
+		rows = append(rows, row)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read table %s: %w", name, err)
+	}
+	return rows, nil
+}
+
+type sqliteColumn struct {
+	name  string
+	rowid bool
+}
+
+// findTable looks up the root page and the columns of a table in the schema table
+func (db *sqliteDatabase) findTable(name string) (int, []sqliteColumn, error) {
+	var rootPage int
+	var sql string
+	err := db.walkTable(1, func(_ int64, payload []byte) error {
+		values, err := db.decodeRecord(payload)
+		if err != nil || len(values) < 5 {
+			return err
+		}
+		if values[0] == "table" && strings.EqualFold(fmt.Sprint(values[1]), name) {
+			page, _ := values[3].(int64)
+			rootPage = int(page)
+			sql, _ = values[4].(string)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read database schema: %w", err)
+	}
+	if rootPage == 0 {
+		return 0, nil, fmt.Errorf("table %s not found", name)
+	}
+	if strings.Contains(strings.ToUpper(sql), "WITHOUT ROWID") {
+		return 0, nil, fmt.Errorf("table %s has no rowid, which is not supported", name)
+	}
+	return rootPage, parseSQLiteColumns(sql), nil
+}
+
+// walkTable calls fn with the rowid and payload of every cell of a table b-tree
+func (db *sqliteDatabase) walkTable(page int, fn func(rowid int64, payload []byte) error) error {
+	visited := make(map[int]bool)
+	var walk func(page int) error
+	walk = func(page int) error {
+		if visited[page] {
+			return fmt.Errorf("page %d is referenced twice", page)
+		}
+		visited[page] = true
+
+		content, err := db.page(page)
+		if err != nil {
+			return err
+		}
+		// The first page starts with the database header
+		offset := 0
+		if page == 1 {
+			offset = 100
+		}
+		if offset+8 > len(content) {
+			return fmt.Errorf("page %d is truncated", page)
+		}
+
+		pageType := content[offset]
+		cellCount := int(binary.BigEndian.Uint16(content[offset+3:]))
+		headerSize := 8
+		if pageType == sqliteTableInterior {
+			headerSize = 12
+		}
+		pointers := offset + headerSize
+		if pointers+2*cellCount > len(content) {
+			return fmt.Errorf("page %d is truncated", page)
+		}
+
+		for i := 0; i < cellCount; i++ {
+			cell := int(binary.BigEndian.Uint16(content[pointers+2*i:]))
+			if cell >= len(content) {
+				return fmt.Errorf("page %d has an invalid cell", page)
+			}
+			switch pageType {
+			case sqliteTableInterior:
+				if cell+4 > len(content) {
+					return fmt.Errorf("page %d has an invalid cell", page)
+				}
+				if err := walk(int(binary.BigEndian.Uint32(content[cell:]))); err != nil {
+					return err
+				}
+			case sqliteTableLeaf:
+				rowid, payload, err := db.leafCell(content, cell)
+				if err != nil {
+					return fmt.Errorf("page %d: %w", page, err)
+				}
+				if err := fn(rowid, payload); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("page %d is not a table page", page)
+			}
+		}
+
+		if pageType == sqliteTableInterior {
+			return walk(int(binary.BigEndian.Uint32(content[offset+8:])))
+		}
+		return nil
+	}
+	return walk(page)
+}
+
+func (db *sqliteDatabase) page(number int) ([]byte, error) {
+	start := (number - 1) * db.pageSize
+	if number < 1 || start+db.pageSize > len(db.data) {
+		return nil, fmt.Errorf("page %d is out of range", number)
+	}
+	return db.data[start : start+db.pageSize], nil
+}
+
+// leafCell reads a table leaf cell, following the overflow pages of large payloads
+func (db *sqliteDatabase) leafCell(content []byte, cell int) (int64, []byte, error) {
+	size, n := sqliteVarint(content[cell:])
+	if n == 0 {
+		return 0, nil, fmt.Errorf("cell payload size is truncated")
+	}
+	cell += n
+	rowid, n := sqliteVarint(content[cell:])
+	if n == 0 {
+		return 0, nil, fmt.Errorf("cell rowid is truncated")
+	}
+	cell += n
+
+	// A payload can't be larger than the file holding it
+	if size > uint64(len(db.data)) {
+		return 0, nil, fmt.Errorf("cell payload size %d is invalid", size)
+	}
+	payloadSize := int(size)
+	local := db.localPayloadSize(payloadSize)
+	if cell+local > len(content) {
+		return 0, nil, fmt.Errorf("cell payload is truncated")
+	}
+	payload := make([]byte, 0, payloadSize)
+	payload = append(payload, content[cell:cell+local]...)
+	if local == payloadSize {
+		return int64(rowid), payload, nil
+	}
+
+	if cell+local+4 > len(content) {
+		return 0, nil, fmt.Errorf("cell overflow pointer is truncated")
+	}
+	overflow := int(binary.BigEndian.Uint32(content[cell+local:]))
+	for pages := 0; overflow != 0 && len(payload) < payloadSize; pages++ {
+		if pages > len(db.data)/db.pageSize {
+			return 0, nil, fmt.Errorf("overflow chain loops")
+		}
+		page, err := db.page(overflow)
+		if err != nil {
+			return 0, nil, err
+		}
+		chunk := page[4:db.usable]
+		if remaining := payloadSize - len(payload); len(chunk) > remaining {
+			chunk = chunk[:remaining]
+		}
+		payload = append(payload, chunk...)
+		overflow = int(binary.BigEndian.Uint32(page))
+	}
+	if len(payload) < payloadSize {
+		return 0, nil, fmt.Errorf("overflow chain is truncated")
+	}
+	return int64(rowid), payload, nil
+}
+
+// localPayloadSize is the part of a table leaf payload stored on the page itself, as defined by the file format
+func (db *sqliteDatabase) localPayloadSize(payloadSize int) int {
+	maxLocal := db.usable - 35
+	if payloadSize <= maxLocal {
+		return payloadSize
+	}
+	minLocal := (db.usable-12)*32/255 - 23
+	local := minLocal + (payloadSize-minLocal)%(db.usable-4)
+	if local > maxLocal {
+		return minLocal
+	}
+	return local
+}
+
+// decodeRecord decodes the values of a record
+func (db *sqliteDatabase) decodeRecord(payload []byte) ([]interface{}, error) {
+	headerSize, n := sqliteVarint(payload)
+	if headerSize > uint64(len(payload)) || n == 0 {
+		return nil, fmt.Errorf("invalid record header")
+	}
+
+	var types []uint64
+	for offset := n; offset < int(headerSize); {
+		serialType, n := sqliteVarint(payload[offset:])
+		if n == 0 {
+			return nil, fmt.Errorf("invalid record header")
+		}
+		types = append(types, serialType)
+		offset += n
+	}
+
+	values := make([]interface{}, 0, len(types))
+	body := payload[headerSize:]
+	for _, serialType := range types {
+		size := sqliteSerialSize(serialType)
+		if size < 0 || size > len(body) {
+			return nil, fmt.Errorf("record is truncated")
+		}
+		field := body[:size]
+		body = body[size:]
+
+		switch {
+		case serialType == 0:
+			values = append(values, nil)
+		case serialType <= 6:
+			values = append(values, sqliteInt(field))
+		case serialType == 7:
+			values = append(values, math.Float64frombits(binary.BigEndian.Uint64(field)))
+		case serialType == 8:
+			values = append(values, int64(0))
+		case serialType == 9:
+			values = append(values, int64(1))
+		case serialType >= 12 && serialType%2 == 0:
+			values = append(values, append([]byte{}, field...))
+		case serialType >= 13:
+			values = append(values, db.decodeText(field))
+		default:
+			return nil, fmt.Errorf("unsupported serial type %d", serialType)
+		}
+	}
+	return values, nil
+}
+
+func (db *sqliteDatabase) decodeText(field []byte) string {
+	if db.encoding == sqliteUTF8 {
+		return string(field)
+	}
+	units := make([]uint16, len(field)/2)
+	for i := range units {
+		if db.encoding == sqliteUTF16LE {
+			units[i] = binary.LittleEndian.Uint16(field[2*i:])
+		} else {
+			units[i] = binary.BigEndian.Uint16(field[2*i:])
+		}
+	}
+	return string(utf16.Decode(units))
+}
+
+func sqliteSerialSize(serialType uint64) int {
+	switch {
+	case serialType <= 4:
+		return int(serialType)
+	case serialType == 5:
+		return 6
+	case serialType == 6 || serialType == 7:
+		return 8
+	case serialType >= 12:
+		return int(serialType-12) / 2
+	}
+	return 0
+}
+
+// sqliteInt decodes a big-endian two's complement integer of 1 to 8 bytes
+func sqliteInt(field []byte) int64 {
+	var value int64
+	if len(field) > 0 && field[0]&0x80 != 0 {
+		value = -1
+	}
+	for _, b := range field {
+		value = value<<8 | int64(b)
+	}
+	return value
+}
+
+// sqliteVarint decodes a SQLite varint and returns its value and length, or a length of 0 when truncated
+func sqliteVarint(data []byte) (uint64, int) {
+	var value uint64
+	for i := 0; i < 9 && i < len(data); i++ {
+		if i == 8 {
+			return value<<8 | uint64(data[i]), 9
+		}
+		value = value<<7 | uint64(data[i]&0x7f)
+		if data[i]&0x80 == 0 {
+			return value, i + 1
+		}
+	}
+	return 0, 0
+}
+
+// parseSQLiteColumns extracts the column names of a CREATE TABLE statement
+func parseSQLiteColumns(sql string) []sqliteColumn {
+	start := strings.Index(sql, "(")
+	end := strings.LastIndex(sql, ")")
+	if start < 0 || end < start {
+		return nil
+	}
+
+	var columns []sqliteColumn
+	for _, definition := range splitSQLiteDefinitions(sql[start+1 : end]) {
+		fields := strings.Fields(definition)
+		if len(fields) == 0 {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "PRIMARY", "UNIQUE", "CONSTRAINT", "CHECK", "FOREIGN":
+			continue
+		}
+
+		name := strings.Trim(fields[0], "\"`[]'")
+		upper := strings.ToUpper(definition)
+		columns = append(columns, sqliteColumn{
+			name:  strings.ToLower(name),
+			rowid: len(fields) > 1 && strings.ToUpper(fields[1]) == "INTEGER" && strings.Contains(upper, "PRIMARY KEY") && !strings.Contains(upper, "DESC"),
+		})
+	}
+	return columns
+}
+
+// splitSQLiteDefinitions splits the column definitions on the commas outside of parentheses and quotes
+func splitSQLiteDefinitions(definitions string) []string {
+	var parts []string
+	depth := 0
+	var quote rune
+	start := 0
+	for i, c := range definitions {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '[':
+			quote = ']'
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, definitions[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, definitions[start:])
+}
+
+// String returns a column as text, or "" when it is NULL
+func (r sqliteRow) String(column string) string {
+	switch value := r[strings.ToLower(column)].(type) {
+	case string:
+		return value
+	case []byte:
+		return string(value)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// Int returns a numeric column as an integer, or 0 when it is NULL or not a number
+func (r sqliteRow) Int(column string) int64 {
+	switch value := r[strings.ToLower(column)].(type) {
+	case int64:
+		return value
+	case float64:
+		return int64(value)
+	}
+	return 0
+}
+
+// Float returns a numeric column as a float, or 0 when it is NULL or not a number
+func (r sqliteRow) Float(column string) float64 {
+	switch value := r[strings.ToLower(column)].(type) {
+	case int64:
+		return float64(value)
+	case float64:
+		return value
+	}
+	return 0
+}
@@ -0,0 +1,30 @@
+-- we can read Lua syntax here!
+return {
+    ["annotations"] = {
+        [1] = {
+            ["chapter"] = "Part 1",
+            ["color"] = "yellow",
+            ["datetime"] = "2024-05-01 10:00:00",
+            ["datetime_updated"] = "2024-05-02 11:30:00",
+            ["drawer"] = "lighten",
+            ["note"] = "Quote this",
+            ["pageno"] = 12,
+            ["pos0"] = "/body/DocFragment[12]/body/p[3]/text().0",
+            ["pos1"] = "/body/DocFragment[12]/body/p[3]/text().30",
+            ["text"] = "Focus is rare and \"valuable\"\
+in the new economy",
+        },
+        [2] = {
+            ["datetime"] = "2024-05-03 09:00:00",
+            ["page"] = "/body/DocFragment[20]/body/p[1]",
+            ["pageno"] = 20,
+        },
+    },
+    ["doc_props"] = {
+        ["authors"] = "Cal Newport\
+Other Author",
+        ["language"] = "en",
+        ["title"] = "Deep Work",
+    },
+    ["partial_md5_checksum"] = "5a1e5bd5c0f8f6b1d3b1c4e1a2b3c4d5",
+}
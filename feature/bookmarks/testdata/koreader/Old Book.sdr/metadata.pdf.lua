@@ -0,0 +1,38 @@
+-- Written by KOReader before the annotations table
+return {
+    ["bookmarks"] = {
+        [1] = {
+            ["datetime"] = "2020-01-02 03:04:05",
+            ["highlighted"] = true,
+            ["notes"] = "Old highlighted text",
+            ["text"] = "An old note",
+        },
+        [2] = {
+            ["datetime"] = "2020-01-03 00:00:00",
+            ["highlighted"] = true,
+            ["notes"] = "Without note",
+            ["text"] = "Page 8 Without note @ 2020-01-03 00:00:00",
+        },
+    },
+    ["highlight"] = {
+        [5] = {
+            [1] = {
+                ["datetime"] = "2020-01-02 03:04:05",
+                ["drawer"] = "underscore",
+                ["text"] = [[Old highlighted text]],
+            },
+        },
+        [8] = {
+            [1] = {
+                ["datetime"] = "2020-01-03 00:00:00",
+                ["drawer"] = "lighten",
+                ["text"] = 'Without note',
+            },
+        },
+    },
+    ["stats"] = {
+        ["authors"] = "Jane Doe",
+        ["title"] = "",
+    },
+    ["doc_path"] = "/mnt/onboard/Old Book.pdf",
+}
@@ -0,0 +1,328 @@
+package bookmarks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WebAnnotationsProvider implements the BookmarksProvider interface by reading a Hypothesis export
+// or any JSON file of W3C Web Annotations. Annotations are grouped into documents by target URI.
+type WebAnnotationsProvider struct {
+	path string
+	localLibrary
+}
+
+// NewWebAnnotationsProvider creates a new WebAnnotationsProvider for the given JSON file
+func NewWebAnnotationsProvider(path string) *WebAnnotationsProvider {
+	p := &WebAnnotationsProvider{path: path}
+	p.load = p.parse
+	return p
+}
+
+// webAnnotation holds the fields of both the Hypothesis API format and the W3C Web Annotation model
+type webAnnotation struct {
+	ID         string          `json:"id"`
+	Created    string          `json:"created"`
+	Updated    string          `json:"updated"`
+	Modified   string          `json:"modified"`
+	URI        string          `json:"uri"`
+	Text       string          `json:"text"`
+	Tags       []string        `json:"tags"`
+	References []string        `json:"references"`
+	Target     json.RawMessage `json:"target"`
+	Body       json.RawMessage `json:"body"`
+	Document   struct {
+		Title []string `json:"title"`
+	} `json:"document"`
+	Links struct {
+		HTML      string `json:"html"`
+		InContext string `json:"incontext"`
+	} `json:"links"`
+}
+
+// webTarget is a W3C target, which is either a URI or an object with a source and selectors
+type webTarget struct {
+	Source   json.RawMessage `json:"source"`
+	Selector json.RawMessage `json:"selector"`
+}
+
+type webSelector struct {
+	Type  string `json:"type"`
+	Exact string `json:"exact"`
+	Start *int   `json:"start"`
+}
+
+type webBody struct {
+	Type    string `json:"type"`
+	Value   string `json:"value"`
+	Purpose string `json:"purpose"`
+}
+
+func (p *WebAnnotationsProvider) parse() (*libraryBuilder, error) {
+	content, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read annotations file: %w", err)
+	}
+	annotations, err := decodeWebAnnotations(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode annotations file: %w", err)
+	}
+
+	type positioned struct {
+		highlight Highlight
+		position  int
+	}
+	builder := newLibraryBuilder()
+	byBook := make(map[int][]positioned)
+	var order []int
+	for _, annotation := range annotations {
+		// Replies belong to the discussion, not to the reader's notes
+		if len(annotation.References) > 0 {
+			continue
+		}
+
+		source, title, selectors := annotation.target()
+		if source == "" {
+			continue
+		}
+		book := builder.addBook(ReadwiseBook{
+			ID:        syntheticID("hypothesis", source),
+			Title:     firstNonEmpty(title, webDocumentTitle(source)),
+			Category:  "articles",
+			Source:    "hypothesis",
+			SourceURL: source,
+			UniqueURL: source,
+		})
+
+		note, bodyTags := annotation.body()
+		quote, position := webQuote(selectors)
+		if quote == "" {
+			// Page notes annotate the whole document
+			if note != "" {
+				book.DocumentNote = strings.TrimSpace(strings.Join([]string{book.DocumentNote, note}, "\n\n"))
+			}
+			continue
+		}
+
+		created := parseWebDate(annotation.Created)
+		updated := parseWebDate(firstNonEmpty(annotation.Updated, annotation.Modified))
+		if updated.IsZero() {
+			updated = created
+		}
+		id := annotation.ID
+		if id == "" {
+			id = source + "\x00" + annotation.Created + "\x00" + normalizeKey(quote)
+		}
+
+		var tags []Tag
+		for _, name := range append(append([]string{}, annotation.Tags...), bodyTags...) {
+			if name = strings.TrimSpace(name); name != "" {
+				tags = append(tags, Tag{Name: name})
+			}
+		}
+
+		if _, ok := byBook[book.ID]; !ok {
+			order = append(order, book.ID)
+		}
+		byBook[book.ID] = append(byBook[book.ID], positioned{
+			position: position,
+			highlight: Highlight{
+				ID:            syntheticID("hypothesis", id),
+				Text:          quote,
+				Note:          note,
+				Location:      position,
+				LocationType:  "offset",
+				HighlightedAt: created,
+				CreatedAt:     created,
+				Updated:       updated,
+				URL:           firstNonEmpty(annotation.Links.InContext, annotation.Links.HTML),
+				ExternalID:    annotation.ID,
+				Tags:          tags,
+			},
+		})
+	}
+
+	// Keep the document order when the annotations have a position
+	for _, bookID := range order {
+		highlights := byBook[bookID]
+		sort.SliceStable(highlights, func(i, j int) bool {
+			return highlights[i].position < highlights[j].position
+		})
+		for _, h := range highlights {
+			builder.addHighlight(bookID, h.highlight)
+		}
+	}
+	return builder, nil
+}
+
+// decodeWebAnnotations accepts a list of annotations, a Hypothesis search result or client export,
+// a W3C AnnotationPage or AnnotationCollection, or a single annotation
+func decodeWebAnnotations(content []byte) ([]webAnnotation, error) {
+	content = bytes.TrimSpace(bytes.TrimPrefix(content, []byte("\ufeff")))
+	if len(content) > 0 && content[0] == '[' {
+		var annotations []webAnnotation
+		err := json.Unmarshal(content, &annotations)
+		return annotations, err
+	}
+
+	var container struct {
+		Rows        []webAnnotation `json:"rows"`
+		Annotations []webAnnotation `json:"annotations"`
+		Items       []webAnnotation `json:"items"`
+		First       *struct {
+			Items []webAnnotation `json:"items"`
+		} `json:"first"`
+		Target json.RawMessage `json:"target"`
+	}
+	if err := json.Unmarshal(content, &container); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case container.Rows != nil:
+		return container.Rows, nil
+	case container.Annotations != nil:
+		return container.Annotations, nil
+	case container.Items != nil:
+		return container.Items, nil
+	case container.First != nil:
+		return container.First.Items, nil
+	case container.Target != nil:
+		var annotation webAnnotation
+		err := json.Unmarshal(content, &annotation)
+		return []webAnnotation{annotation}, err
+	}
+	return nil, fmt.Errorf("no annotations found")
+}
+
+// target returns the annotated document, its title when known, and the selectors of the first target
+func (a webAnnotation) target() (string, string, []webSelector) {
+	title := ""
+	if len(a.Document.Title) > 0 {
+		title = strings.TrimSpace(a.Document.Title[0])
+	}
+
+	targets := jsonList(a.Target)
+	for _, raw := range targets {
+		var uri string
+		if json.Unmarshal(raw, &uri) == nil {
+			return firstNonEmpty(a.URI, uri), title, nil
+		}
+
+		var target webTarget
+		if json.Unmarshal(raw, &target) != nil {
+			continue
+		}
+		// The source is a URI, or an object with an id and sometimes a title
+		source := jsonString(target.Source)
+		var described struct {
+			ID    string `json:"id"`
+			Title string `json:"title"`
+		}
+		if source == "" && json.Unmarshal(target.Source, &described) == nil {
+			source = described.ID
+			title = firstNonEmpty(title, described.Title)
+		}
+
+		var selectors []webSelector
+		for _, rawSelector := range jsonList(target.Selector) {
+			var selector webSelector
+			if json.Unmarshal(rawSelector, &selector) == nil {
+				selectors = append(selectors, selector)
+			}
+		}
+		return firstNonEmpty(a.URI, source), title, selectors
+	}
+	return a.URI, title, nil
+}
+
+// body returns the note and the tags of the annotation. Hypothesis keeps the note in text,
+// the W3C model in textual bodies, with tags as bodies whose purpose is tagging.
+func (a webAnnotation) body() (string, []string) {
+	notes := []string{strings.TrimSpace(a.Text)}
+	var tags []string
+	for _, raw := range jsonList(a.Body) {
+		if value := jsonString(raw); value != "" {
+			notes = append(notes, value)
+			continue
+		}
+		var body webBody
+		if json.Unmarshal(raw, &body) != nil || body.Value == "" {
+			continue
+		}
+		if body.Purpose == "tagging" {
+			tags = append(tags, body.Value)
+		} else {
+			notes = append(notes, strings.TrimSpace(body.Value))
+		}
+	}
+
+	var note []string
+	for _, value := range notes {
+		if value != "" {
+			note = append(note, value)
+		}
+	}
+	return strings.Join(note, "\n\n"), tags
+}
+
+// webQuote returns the quoted text and its position in the document, when a selector gives it
+func webQuote(selectors []webSelector) (string, int) {
+	quote, position := "", 0
+	for _, selector := range selectors {
+		switch selector.Type {
+		case "TextQuoteSelector":
+			quote = strings.TrimSpace(selector.Exact)
+		case "TextPositionSelector":
+			if selector.Start != nil {
+				position = *selector.Start
+			}
+		}
+	}
+	return quote, position
+}
+
+// webDocumentTitle derives a title from the URI of documents without one
+func webDocumentTitle(source string) string {
+	parsed, err := url.Parse(source)
+	if err != nil || parsed.Host == "" {
+		return source
+	}
+	return strings.TrimSuffix(parsed.Host+parsed.Path, "/")
+}
+
+// jsonList returns the elements of a JSON array, or the value itself when it isn't an array
+func jsonList(raw json.RawMessage) []json.RawMessage {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	var list []json.RawMessage
+	if raw[0] == '[' && json.Unmarshal(raw, &list) == nil {
+		return list
+	}
+	return []json.RawMessage{raw}
+}
+
+// jsonString returns the value of a JSON string, or "" for any other value
+func jsonString(raw json.RawMessage) string {
+	var value string
+	if json.Unmarshal(raw, &value) != nil {
+		return ""
+	}
+	return value
+}
+
+func parseWebDate(value string) time.Time {
+	date, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return date
+}
@@ -0,0 +1,105 @@
+package bookmarks
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWebAnnotationsProvider(t *testing.T) {
+	tests := []struct {
+		file       string
+		book       ReadwiseBook
+		highlights []Highlight
+	}{
+		{
+			// Hypothesis client export: the reply is skipped, the page note becomes the document note,
+			// the highlights are sorted by their position in the page
+			file: "export.json",
+			book: ReadwiseBook{Title: "Deep Work, an Essay", Category: "articles", SourceURL: "https://example.com/deep-work", DocumentNote: "An essay about attention"},
+			highlights: []Highlight{
+				{Text: "Focus is rare", Location: 120, URL: "https://hyp.is/hyp-1/example.com/deep-work", ExternalID: "hyp-1",
+					HighlightedAt: time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC), Updated: time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)},
+				{Text: "Deep work is valuable", Note: "Worth a reread", Location: 900, URL: "https://hyp.is/hyp-2/example.com/deep-work", ExternalID: "hyp-2",
+					HighlightedAt: time.Date(2024, 2, 2, 10, 0, 0, 0, time.UTC), Updated: time.Date(2024, 2, 3, 10, 0, 0, 0, time.UTC), Tags: []Tag{{Name: "focus"}}},
+			},
+		},
+		{
+			// W3C AnnotationPage: textual bodies become the note, tagging bodies the tags
+			file: "annotation-page.json",
+			book: ReadwiseBook{Title: "Walden", Category: "articles", SourceURL: "https://example.org/walden"},
+			highlights: []Highlight{
+				{Text: "I went to the woods", Note: "A plain body", ExternalID: "https://example.org/anno/1",
+					HighlightedAt: time.Date(2024, 4, 1, 9, 0, 0, 0, time.UTC), Updated: time.Date(2024, 4, 1, 9, 0, 0, 0, time.UTC)},
+				{Text: "Simplify, simplify", Note: "Compare with Thoreau", Location: 50, ExternalID: "https://example.org/anno/2",
+					HighlightedAt: time.Date(2024, 4, 2, 9, 0, 0, 0, time.UTC), Updated: time.Date(2024, 4, 3, 9, 0, 0, 0, time.UTC), Tags: []Tag{{Name: "simplicity"}}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.file, func(t *testing.T) {
+			provider := NewWebAnnotationsProvider(filepath.Join("testdata", "hypothesis", test.file))
+			books, err := provider.GetBooks()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(books) != 1 {
+				t.Fatalf("got %d books, want 1", len(books))
+			}
+			book, want := books[0], test.book
+			if book.Title != want.Title || book.Category != want.Category || book.SourceURL != want.SourceURL ||
+				book.DocumentNote != want.DocumentNote || book.Source != "hypothesis" {
+				t.Errorf("got book %+v, want %+v", book, want)
+			}
+
+			highlights, err := provider.GetHighlights(book.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(highlights) != len(test.highlights) {
+				t.Fatalf("got %d highlights, want %d", len(highlights), len(test.highlights))
+			}
+			for i, want := range test.highlights {
+				got := highlights[i]
+				if got.Text != want.Text || got.Note != want.Note || got.Location != want.Location || got.URL != want.URL ||
+					got.ExternalID != want.ExternalID || !got.HighlightedAt.Equal(want.HighlightedAt) || !got.Updated.Equal(want.Updated) ||
+					!reflect.DeepEqual(got.Tags, want.Tags) {
+					t.Errorf("got highlight %+v, want %+v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeWebAnnotations(t *testing.T) {
+	annotation := `{"id": "a", "target": "https://example.com"}`
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "array", content: `[` + annotation + `]`},
+		{name: "search result", content: `{"total": 1, "rows": [` + annotation + `]}`},
+		{name: "client export", content: `{"annotations": [` + annotation + `]}`},
+		{name: "annotation page", content: `{"type": "AnnotationPage", "items": [` + annotation + `]}`},
+		{name: "annotation collection", content: `{"type": "AnnotationCollection", "first": {"items": [` + annotation + `]}}`},
+		{name: "single annotation", content: "\ufeff" + annotation},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			annotations, err := decodeWebAnnotations([]byte(test.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(annotations) != 1 || annotations[0].ID != "a" {
+				t.Errorf("got %+v, want the annotation a", annotations)
+			}
+		})
+	}
+
+	if _, err := decodeWebAnnotations([]byte(`{"total": 0}`)); err == nil {
+		t.Error("got no error for a file without annotations")
+	}
+}
@@ -0,0 +1,314 @@
+package bookmarks
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ZoteroProvider implements the BookmarksProvider interface by reading a Zotero JSON export,
+// either a Better BibTeX JSON export or the items of the Zotero web API, with their annotations
+type ZoteroProvider struct {
+	path string
+	localLibrary
+}
+
+// NewZoteroProvider creates a new ZoteroProvider for the given JSON file
+func NewZoteroProvider(path string) *ZoteroProvider {
+	p := &ZoteroProvider{path: path}
+	p.load = p.parse
+	return p
+}
+
+// zoteroItem holds the fields of the regular items, attachments and annotations that are used
+type zoteroItem struct {
+	Key          string          `json:"key"`
+	ItemKey      string          `json:"itemKey"`
+	ItemID       json.Number     `json:"itemID"`
+	ItemType     string          `json:"itemType"`
+	ParentItem   string          `json:"parentItem"`
+	Title        string          `json:"title"`
+	Creators     []zoteroCreator `json:"creators"`
+	Date         string          `json:"date"`
+	DOI          string          `json:"DOI"`
+	URL          string          `json:"url"`
+	AbstractNote string          `json:"abstractNote"`
+	Extra        string          `json:"extra"`
+	CitationKey  string          `json:"citationKey"`
+	Citekey      string          `json:"citekey"`
+	Language     string          `json:"language"`
+	ISBN         string          `json:"ISBN"`
+	Tags         []zoteroTag     `json:"tags"`
+	DateAdded    string          `json:"dateAdded"`
+	DateModified string          `json:"dateModified"`
+
+	AnnotationType      string `json:"annotationType"`
+	AnnotationText      string `json:"annotationText"`
+	AnnotationComment   string `json:"annotationComment"`
+	AnnotationColor     string `json:"annotationColor"`
+	AnnotationPageLabel string `json:"annotationPageLabel"`
+	AnnotationSortIndex string `json:"annotationSortIndex"`
+
+	// Better BibTeX nests the attachments, and sometimes their annotations, in the items
+	Attachments []zoteroItem `json:"attachments"`
+	Annotations []zoteroItem `json:"annotations"`
+	// The web API wraps the fields in data
+	Data *zoteroItem `json:"data"`
+}
+
+type zoteroCreator struct {
+	CreatorType string `json:"creatorType"`
+	FirstName   string `json:"firstName"`
+	LastName    string `json:"lastName"`
+	Name        string `json:"name"`
+}
+
+type zoteroTag struct {
+	Tag string `json:"tag"`
+}
+
+// zoteroColors names the colors of the Zotero annotation palette
+var zoteroColors = map[string]string{
+	"#ffd400": "yellow",
+	"#ff6666": "red",
+	"#5fb236": "green",
+	"#2ea8e5": "blue",
+	"#a28ae5": "purple",
+	"#e56eee": "magenta",
+	"#f19837": "orange",
+	"#aaaaaa": "gray",
+}
+
+var (
+	zoteroYearPattern        = regexp.MustCompile(`\b(\d{4})\b`)
+	zoteroCitationKeyPattern = regexp.MustCompile(`(?im)^\s*citation key:\s*(\S+)`)
+)
+
+var zoteroDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+}
+
+func (p *ZoteroProvider) parse() (*libraryBuilder, error) {
+	content, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Zotero export: %w", err)
+	}
+	return parseZoteroExport(content)
+}
+
+// parseZoteroExport groups the annotations of an export by the item they belong to
+func parseZoteroExport(content []byte) (*libraryBuilder, error) {
+	var items []zoteroItem
+	var err error
+	if trimmed := strings.TrimSpace(string(content)); strings.HasPrefix(trimmed, "[") {
+		err = json.Unmarshal(content, &items)
+	} else {
+		var export struct {
+			Items []zoteroItem `json:"items"`
+		}
+		err = json.Unmarshal(content, &export)
+		items = export.Items
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode Zotero export: %w", err)
+	}
+
+	// Index every item by key, with the nested attachments and annotations pointing to their parent
+	byKey := make(map[string]*zoteroItem)
+	var annotations []*zoteroItem
+	var index func(item zoteroItem, parent string)
+	index = func(item zoteroItem, parent string) {
+		if item.Data != nil {
+			data := *item.Data
+			data.Key = firstNonEmpty(data.Key, item.Key)
+			item = data
+		}
+		item.Key = firstNonEmpty(item.Key, item.ItemKey, item.ItemID.String())
+		if item.ParentItem == "" {
+			item.ParentItem = parent
+		}
+
+		stored := item
+		if stored.Key != "" {
+			byKey[stored.Key] = &stored
+		}
+		if stored.ItemType == "annotation" {
+			annotations = append(annotations, &stored)
+		}
+		for _, child := range item.Attachments {
+			index(child, stored.Key)
+		}
+		for _, child := range item.Annotations {
+			child.ItemType = firstNonEmpty(child.ItemType, "annotation")
+			index(child, stored.Key)
+		}
+	}
+	for _, item := range items {
+		index(item, "")
+	}
+
+	// Annotations belong to an attachment, which usually belongs to a regular item
+	sort.SliceStable(annotations, func(i, j int) bool {
+		return annotations[i].AnnotationSortIndex < annotations[j].AnnotationSortIndex
+	})
+	builder := newLibraryBuilder()
+	for _, annotation := range annotations {
+		if annotation.AnnotationType == "image" || annotation.AnnotationType == "ink" {
+			continue
+		}
+		item := byKey[annotation.ParentItem]
+		if item != nil && item.ItemType == "attachment" && byKey[item.ParentItem] != nil {
+			item = byKey[item.ParentItem]
+		}
+		if item == nil {
+			fmt.Printf("Warning: skipping Zotero annotation %s without a parent item\n", annotation.Key)
+			continue
+		}
+
+		book := builder.addBook(zoteroBook(item))
+		highlight, ok := zoteroHighlight(annotation)
+		if ok {
+			builder.addHighlight(book.ID, highlight)
+		}
+	}
+	return builder, nil
+}
+
+func zoteroBook(item *zoteroItem) ReadwiseBook {
+	var authors, others []string
+	for _, creator := range item.Creators {
+		name := strings.TrimSpace(firstNonEmpty(creator.Name, strings.TrimSpace(creator.FirstName+" "+creator.LastName)))
+		if name == "" {
+			continue
+		}
+		if creator.CreatorType == "" || creator.CreatorType == "author" {
+			authors = append(authors, name)
+		} else {
+			others = append(others, name)
+		}
+	}
+	// Edited volumes have no authors
+	if len(authors) == 0 {
+		authors = others
+	}
+
+	citationKey := firstNonEmpty(item.CitationKey, item.Citekey)
+	if match := zoteroCitationKeyPattern.FindStringSubmatch(item.Extra); citationKey == "" && match != nil {
+		citationKey = match[1]
+	}
+	year := 0
+	if match := zoteroYearPattern.FindStringSubmatch(item.Date); match != nil {
+		year, _ = strconv.Atoi(match[1])
+	}
+
+	category := "articles"
+	switch item.ItemType {
+	case "book", "bookSection":
+		category = "books"
+	}
+
+	var tags []Tag
+	for _, tag := range item.Tags {
+		if name := strings.TrimSpace(tag.Tag); name != "" {
+			tags = append(tags, Tag{Name: name})
+		}
+	}
+
+	sourceURL := item.URL
+	if sourceURL == "" && item.DOI != "" {
+		sourceURL = "https://doi.org/" + item.DOI
+	}
+
+	return ReadwiseBook{
+		ID:          syntheticID("zotero", item.Key),
+		Title:       item.Title,
+		Author:      strings.Join(authors, "; "),
+		Category:    category,
+		Source:      "zotero",
+		SourceURL:   sourceURL,
+		UniqueURL:   "zotero://select/library/items/" + item.Key,
+		Summary:     item.AbstractNote,
+		Tags:        tags,
+		Language:    item.Language,
+		ISBN:        item.ISBN,
+		DOI:         item.DOI,
+		CitationKey: citationKey,
+		Year:        year,
+	}
+}
+
+// zoteroHighlight converts an annotation, the text of note annotations is their comment
+func zoteroHighlight(annotation *zoteroItem) (Highlight, bool) {
+	text := strings.TrimSpace(annotation.AnnotationText)
+	note := strings.TrimSpace(annotation.AnnotationComment)
+	if text == "" {
+		text, note = note, ""
+	}
+	if text == "" {
+		return Highlight{}, false
+	}
+
+	created := parseZoteroDate(annotation.DateAdded)
+	updated := parseZoteroDate(annotation.DateModified)
+	if updated.IsZero() {
+		updated = created
+	}
+
+	color := strings.ToLower(annotation.AnnotationColor)
+	if name, ok := zoteroColors[color]; ok {
+		color = name
+	}
+
+	var tags []Tag
+	for _, tag := range annotation.Tags {
+		if name := strings.TrimSpace(tag.Tag); name != "" {
+			tags = append(tags, Tag{Name: name})
+		}
+	}
+
+	highlight := Highlight{
+		ID:            zoteroAnnotationID(annotation),
+		Text:          text,
+		Note:          note,
+		Color:         color,
+		PageLabel:     annotation.AnnotationPageLabel,
+		HighlightedAt: created,
+		CreatedAt:     created,
+		Updated:       updated,
+		ExternalID:    annotation.Key,
+		Tags:          tags,
+	}
+	if page, err := strconv.Atoi(annotation.AnnotationPageLabel); err == nil {
+		highlight.Location = page
+		highlight.EndLocation = page
+		highlight.LocationType = "page"
+	}
+	return highlight, true
+}
+
+// zoteroAnnotationID derives the highlight ID from the annotation key. Exports that nest the
+// annotations without their key identify them by attachment and position instead.
+func zoteroAnnotationID(annotation *zoteroItem) int {
+	if annotation.Key != "" {
+		return syntheticID("zotero", annotation.Key)
+	}
+	if annotation.AnnotationSortIndex != "" {
+		return syntheticID("zotero", annotation.ParentItem, annotation.AnnotationSortIndex)
+	}
+	return syntheticID("zotero", annotation.ParentItem, normalizeKey(annotation.AnnotationText), normalizeKey(annotation.AnnotationComment))
+}
+
+func parseZoteroDate(value string) time.Time {
+	for _, layout := range zoteroDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,99 @@
+package bookmarks
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestZoteroProvider(t *testing.T) {
+	tests := []struct {
+		file       string
+		book       ReadwiseBook
+		highlights []Highlight
+	}{
+		{
+			// Better BibTeX nests the annotations without their key, the image annotation is dropped
+			file: "betterbibtex.json",
+			book: ReadwiseBook{Title: "Deep Work", Author: "Cal Newport", Category: "books", CitationKey: "newport2016", Year: 2016, ISBN: "9781455586691"},
+			highlights: []Highlight{
+				{Text: "First on page 11", Note: "A comment", Color: "yellow", PageLabel: "11", Location: 11,
+					HighlightedAt: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), Updated: time.Date(2024, 1, 3, 10, 0, 0, 0, time.UTC)},
+				{Text: "Second on page 12", Color: "blue", PageLabel: "12", Location: 12,
+					HighlightedAt: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC), Updated: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)},
+				{Text: "A note annotation", PageLabel: "xii"},
+			},
+		},
+		{
+			// Web API items wrapped in data, the annotation of a missing item is skipped
+			file: "webapi.json",
+			book: ReadwiseBook{Title: "An Article", Author: "Ada Lovelace", Category: "articles", SourceURL: "https://doi.org/10.1000/xyz", Year: 1843},
+			highlights: []Highlight{
+				{Text: "The Analytical Engine weaves algebraic patterns", Color: "green", PageLabel: "3", Location: 3,
+					HighlightedAt: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), Updated: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.file, func(t *testing.T) {
+			provider := NewZoteroProvider(filepath.Join("testdata", "zotero", test.file))
+			books, err := provider.GetBooks()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(books) != 1 {
+				t.Fatalf("got %d books, want 1", len(books))
+			}
+			book, want := books[0], test.book
+			if book.Title != want.Title || book.Author != want.Author || book.Category != want.Category || book.CitationKey != want.CitationKey ||
+				book.SourceURL != want.SourceURL || book.Year != want.Year || book.ISBN != want.ISBN {
+				t.Errorf("got book %+v, want %+v", book, want)
+			}
+
+			highlights, err := provider.GetHighlights(book.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(highlights) != len(test.highlights) {
+				t.Fatalf("got %d highlights, want %d", len(highlights), len(test.highlights))
+			}
+			ids := make(map[int]bool)
+			for i, want := range test.highlights {
+				got := highlights[i]
+				if got.Text != want.Text || got.Note != want.Note || got.Color != want.Color || got.PageLabel != want.PageLabel ||
+					got.Location != want.Location || !got.HighlightedAt.Equal(want.HighlightedAt) || !got.Updated.Equal(want.Updated) {
+					t.Errorf("got highlight %+v, want %+v", got, want)
+				}
+				if ids[got.ID] {
+					t.Errorf("highlight %q has the ID %d of another highlight", got.Text, got.ID)
+				}
+				ids[got.ID] = true
+			}
+		})
+	}
+}
+
+func FuzzParseZoteroExport(f *testing.F) {
+	files, err := filepath.Glob(filepath.Join("testdata", "zotero", "*.json"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(content)
+	}
+	f.Add([]byte(`[{"data": {"data": {}}, "annotations": [{"annotations": [{}]}]}]`))
+
+	f.Fuzz(func(t *testing.T, content []byte) {
+		builder, err := parseZoteroExport(content)
+		if err != nil {
+			return
+		}
+		builder.build()
+	})
+}
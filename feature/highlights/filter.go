@@ -0,0 +1,113 @@
+package highlights
+
+import (
+	"anytype-readwise/core"
+	"anytype-readwise/feature/bookmarks"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Filter drops the highlights that don't meet the configured conditions
+type Filter struct {
+	config       core.HighlightFilter
+	since        time.Time
+	until        time.Time
+	textRegex    *regexp.Regexp
+	excludeRegex *regexp.Regexp
+}
+
+// NewFilter compiles the highlight filter configuration
+func NewFilter(config core.HighlightFilter) (*Filter, error) {
+	filter := &Filter{config: config}
+
+	var err error
+	if config.Since != "" {
+		if filter.since, err = core.ParseDate(config.Since); err != nil {
+			return nil, fmt.Errorf("invalid since date: %w", err)
+		}
+	}
+	if config.Until != "" {
+		if filter.until, err = core.ParseDate(config.Until); err != nil {
+			return nil, fmt.Errorf("invalid until date: %w", err)
+		}
+		// A plain date includes the whole day
+		if len(config.Until) == len("2006-01-02") {
+			filter.until = filter.until.AddDate(0, 0, 1)
+		}
+	}
+	if config.TextRegex != "" {
+		if filter.textRegex, err = regexp.Compile(config.TextRegex); err != nil {
+			return nil, fmt.Errorf("invalid text_regex: %w", err)
+		}
+	}
+	if config.ExcludeRegex != "" {
+		if filter.excludeRegex, err = regexp.Compile(config.ExcludeRegex); err != nil {
+			return nil, fmt.Errorf("invalid exclude_regex: %w", err)
+		}
+	}
+
+	return filter, nil
+}
+
+// Process returns the highlights that pass the filter
+func (f *Filter) Process(book bookmarks.ReadwiseBook, highlights []bookmarks.Highlight) []bookmarks.Highlight {
+	kept := make([]bookmarks.Highlight, 0, len(highlights))
+	for _, highlight := range highlights {
+		if f.Keep(highlight) {
+			kept = append(kept, highlight)
+		}
+	}
+	return kept
+}
+
+// Keep reports whether a highlight passes the filter.
+// Headings are not subject to the length and text conditions.
+func (f *Filter) Keep(highlight bookmarks.Highlight) bool {
+	if highlight.IsDiscard && !f.config.IncludeDiscarded {
+		return false
+	}
+	if len(f.config.IncludeTags) > 0 && !hasAnyTag(highlight, f.config.IncludeTags) {
+		return false
+	}
+	if len(f.config.ExcludeTags) > 0 && hasAnyTag(highlight, f.config.ExcludeTags) {
+		return false
+	}
+	if len(f.config.Colors) > 0 && !core.ContainsFold(f.config.Colors, highlight.Color) {
+		return false
+	}
+	if len(f.config.ExcludeColors) > 0 && core.ContainsFold(f.config.ExcludeColors, highlight.Color) {
+		return false
+	}
+	if !f.since.IsZero() && highlight.HighlightedAt.Before(f.since) {
+		return false
+	}
+	if !f.until.IsZero() && !highlight.HighlightedAt.Before(f.until) {
+		return false
+	}
+
+	if highlight.HeadingLevel > 0 {
+		return true
+	}
+	if f.config.MinLength > 0 && utf8.RuneCountInString(strings.TrimSpace(highlight.Text)) < f.config.MinLength {
+		return false
+	}
+	if f.textRegex != nil && !f.textRegex.MatchString(highlight.Text) {
+		return false
+	}
+	if f.excludeRegex != nil && f.excludeRegex.MatchString(highlight.Text) {
+		return false
+	}
+	return true
+}
+
+func hasAnyTag(highlight bookmarks.Highlight, names []string) bool {
+	for _, tag := range highlight.Tags {
+		if core.ContainsFold(names, tag.Name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+package highlights
+
+import (
+	"anytype-readwise/core"
+	"anytype-readwise/feature/bookmarks"
+	"testing"
+)
+
+func TestFilterDiscarded(t *testing.T) {
+	highlights := []bookmarks.Highlight{
+		{ID: 1, Text: "Kept"},
+		{ID: 2, Text: "Discarded in the review", IsDiscard: true},
+	}
+
+	filter, err := NewFilter(core.HighlightFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kept := filter.Process(bookmarks.ReadwiseBook{}, highlights); len(kept) != 1 || kept[0].ID != 1 {
+		t.Errorf("got %+v, want only the highlight that was not discarded", kept)
+	}
+
+	filter, err = NewFilter(core.HighlightFilter{IncludeDiscarded: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kept := filter.Process(bookmarks.ReadwiseBook{}, highlights); len(kept) != 2 {
+		t.Errorf("got %d highlights, want both with include_discarded", len(kept))
+	}
+}
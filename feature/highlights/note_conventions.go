@@ -0,0 +1,154 @@
+package highlights
+
+import (
+	"anytype-readwise/feature/bookmarks"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	headingToken = regexp.MustCompile(`^\.h([1-6])$`)
+	concatToken  = regexp.MustCompile(`^\.c([0-9]+)$`)
+	tagToken     = regexp.MustCompile(`^\.([\p{L}_][\p{L}\p{N}_-]*)$`)
+)
+
+// concatSeparator joins the text of concatenated highlights
+const concatSeparator = " ... "
+
+// NoteConventions interprets the Readwise inline note conventions:
+// ".h1" to ".h6" turn the highlight into a heading, ".tagname" tags the highlight
+// and ".c1", ".c2"... join consecutive highlights into one.
+type NoteConventions struct{}
+
+// NewNoteConventions creates a new NoteConventions processor
+func NewNoteConventions() *NoteConventions {
+	return &NoteConventions{}
+}
+
+// Process parses the notes of the highlights and applies their conventions
+func (p *NoteConventions) Process(book bookmarks.ReadwiseBook, highlights []bookmarks.Highlight) []bookmarks.Highlight {
+	parsed := make([]bookmarks.Highlight, 0, len(highlights))
+	chains := make([]int, 0, len(highlights))
+	hasChains := false
+
+	for _, highlight := range highlights {
+		note, headingLevel, chain, tags := parseNote(highlight.Note)
+		highlight.Note = note
+		if headingLevel > 0 {
+			highlight.HeadingLevel = headingLevel
+		}
+		highlight.Tags = appendTags(highlight.Tags, tags)
+
+		parsed = append(parsed, highlight)
+		chains = append(chains, chain)
+		hasChains = hasChains || chain > 0
+	}
+
+	if !hasChains {
+		return parsed
+	}
+	return concatenate(parsed, chains)
+}
+
+// parseNote extracts the convention tokens of a note made only of tokens, and returns what is left of it.
+// Notes with any other word are kept as they are, so that prose like ".NET" or ".js" isn't taken for tags.
+func parseNote(note string) (rest string, headingLevel int, chain int, tags []string) {
+	for _, word := range strings.Fields(note) {
+		if match := headingToken.FindStringSubmatch(word); match != nil {
+			headingLevel, _ = strconv.Atoi(match[1])
+			continue
+		}
+		if match := concatToken.FindStringSubmatch(word); match != nil {
+			chain, _ = strconv.Atoi(match[1])
+			continue
+		}
+		if match := tagToken.FindStringSubmatch(word); match != nil {
+			tags = append(tags, match[1])
+			continue
+		}
+		return note, 0, 0, nil
+	}
+	return "", headingLevel, chain, tags
+}
+
+// concatenate joins the highlights of every ".c1", ".c2"... chain into the first one.
+// Chains are detected in reading order, by location, while the highlights keep the order of
+// the source, each chain taking the place of its first highlight.
+func concatenate(highlights []bookmarks.Highlight, chains []int) []bookmarks.Highlight {
+	order := make([]int, len(highlights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return highlights[order[a]].Location < highlights[order[b]].Location
+	})
+
+	followers := make(map[int][]int) // Highlights appended to the first one of their chain, in reading order
+	joined := make([]bool, len(highlights))
+	first := -1 // Index of the highlight the chain is appended to
+	lastChain := 0
+	for _, i := range order {
+		chain := chains[i]
+		if chain > 1 && first >= 0 && chain == lastChain+1 {
+			followers[first] = append(followers[first], i)
+			joined[i] = true
+			lastChain = chain
+			continue
+		}
+		if chain > 0 {
+			first = i
+			lastChain = chain
+		} else {
+			first = -1
+			lastChain = 0
+		}
+	}
+
+	var result []bookmarks.Highlight
+	for i, merged := range highlights {
+		if joined[i] {
+			continue
+		}
+		for _, j := range followers[i] {
+			highlight := highlights[j]
+			merged.Text += concatSeparator + highlight.Text
+			if highlight.Note != "" {
+				if merged.Note != "" {
+					merged.Note += "\n"
+				}
+				merged.Note += highlight.Note
+			}
+			merged.Tags = appendTagList(merged.Tags, highlight.Tags)
+			merged.MergedIDs = append(merged.MergedIDs, highlight.ID)
+			merged.MergedIDs = append(merged.MergedIDs, highlight.MergedIDs...)
+		}
+		result = append(result, merged)
+	}
+	return result
+}
+
+// appendTags adds the named tags that the highlight doesn't have yet
+func appendTags(tags []bookmarks.Tag, names []string) []bookmarks.Tag {
+	for _, name := range names {
+		tags = appendTagList(tags, []bookmarks.Tag{{Name: name}})
+	}
+	return tags
+}
+
+func appendTagList(tags []bookmarks.Tag, extra []bookmarks.Tag) []bookmarks.Tag {
+	for _, tag := range extra {
+		duplicate := false
+		for _, existing := range tags {
+			if strings.EqualFold(existing.Name, tag.Name) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
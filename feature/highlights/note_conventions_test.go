@@ -0,0 +1,54 @@
+package highlights
+
+import (
+	"anytype-readwise/feature/bookmarks"
+	"reflect"
+	"testing"
+)
+
+func TestParseNote(t *testing.T) {
+	tests := []struct {
+		note    string
+		rest    string
+		heading int
+		chain   int
+		tags    []string
+	}{
+		{note: ".h2", heading: 2},
+		{note: ".c1 .focus\n.deep_work", chain: 1, tags: []string{"focus", "deep_work"}},
+		{note: "Ported to .NET", rest: "Ported to .NET"},
+		{note: "See the .js file .h1", rest: "See the .js file .h1"},
+		{note: "Plain note", rest: "Plain note"},
+		{note: ""},
+	}
+	for _, test := range tests {
+		rest, heading, chain, tags := parseNote(test.note)
+		if rest != test.rest || heading != test.heading || chain != test.chain || !reflect.DeepEqual(tags, test.tags) {
+			t.Errorf("parseNote(%q) = %q, %d, %d, %v, want %q, %d, %d, %v",
+				test.note, rest, heading, chain, tags, test.rest, test.heading, test.chain, test.tags)
+		}
+	}
+}
+
+func TestConcatenateKeepsSourceOrder(t *testing.T) {
+	// Sorted by date in the source: the second part of the chain was highlighted first
+	highlights := []bookmarks.Highlight{
+		{ID: 1, Text: "Second part", Location: 20},
+		{ID: 2, Text: "Unrelated", Location: 50},
+		{ID: 3, Text: "First part", Location: 10},
+		{ID: 4, Text: "Before everything", Location: 5},
+	}
+	chains := []int{2, 0, 1, 0}
+
+	result := concatenate(highlights, chains)
+	var ids []int
+	for _, highlight := range result {
+		ids = append(ids, highlight.ID)
+	}
+	if want := []int{2, 3, 4}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("got highlights %v, want %v", ids, want)
+	}
+	if merged := result[1]; merged.Text != "First part"+concatSeparator+"Second part" || !reflect.DeepEqual(merged.MergedIDs, []int{1}) {
+		t.Errorf("got %+v, want the chain joined in reading order", merged)
+	}
+}
@@ -0,0 +1,22 @@
+package highlights
+
+import (
+	"anytype-readwise/feature/bookmarks"
+)
+
+// Processor transforms the highlights of a book before they are rendered
+type Processor interface {
+	// Process returns the processed highlights of the book
+	Process(book bookmarks.ReadwiseBook, highlights []bookmarks.Highlight) []bookmarks.Highlight
+}
+
+// Pipeline runs several processors in order
+type Pipeline []Processor
+
+// Process runs every processor of the pipeline on the highlights
+func (p Pipeline) Process(book bookmarks.ReadwiseBook, highlights []bookmarks.Highlight) []bookmarks.Highlight {
+	for _, processor := range p {
+		highlights = processor.Process(book, highlights)
+	}
+	return highlights
+}
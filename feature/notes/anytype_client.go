@@ -5,6 +5,7 @@ import (
 	"anytype-readwise/feature/bookmarks"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -24,6 +25,26 @@ type AnytypeObject struct {
 	Name string `json:"name"`
 }
 
+// BookObjectOptions describes how a book is stored in Anytype.
+// Empty fields fall back to the client configuration.
+type BookObjectOptions struct {
+	Name       string
+	ObjectType string
+	Icon       string
+	// IconFileID is an uploaded image used as icon instead of the emoji
+	IconFileID string
+	// CoverFileID is an uploaded image stored in CoverProperty
+	CoverFileID   string
+	CoverProperty string
+	// Properties are extra properties set on the object
+	Properties []CreateObjectProperty
+	// ObjectID is the object the book was synced to before, updated in place while it exists
+	// even when the book is now routed to another type
+	ObjectID string
+	// Authors are the names split from the author field, linked from the import bundle
+	Authors []string
+}
+
 func NewAnytypeClient(apiKey, baseURL, version string, config *core.Config) *AnytypeClient {
 	return &AnytypeClient{
 		apiKey:  apiKey,
@@ -56,8 +77,30 @@ func (c *AnytypeClient) makeRequest(method, endpoint string, body interface{}) (
 	return c.httpClient.Do(req)
 }
 
-func (c *AnytypeClient) CreateOrUpdateNoteFromBook(spaceID string, book bookmarks.ReadwiseBook, content string) (*AnytypeObject, error) {
-	objects, err := c.GetObjects(spaceID, c.config.ObjectType)
+func (c *AnytypeClient) CreateOrUpdateNoteFromBook(spaceID string, book bookmarks.ReadwiseBook, content string, opts BookObjectOptions) (*AnytypeObject, error) {
+	if opts.ObjectType == "" {
+		opts.ObjectType = c.config.ObjectType
+	}
+	if opts.Icon == "" {
+		opts.Icon = "📚"
+	}
+
+	if opts.ObjectID != "" {
+		obj, err := c.GetObject(spaceID, opts.ObjectID)
+		if err != nil && !errors.Is(err, core.ErrNotFound) {
+			return nil, fmt.Errorf("failed to get object %s: %w", opts.ObjectID, err)
+		}
+		if err == nil && !obj.Archived {
+			req := c.CreateBookUpdateRequest(book, *obj, content, opts)
+			updatedObject, err := c.UpdateObject(spaceID, obj.ID, req)
+			if err != nil {
+				return nil, fmt.Errorf("failed to update object: %w", err)
+			}
+			return updatedObject, nil
+		}
+	}
+
+	objects, err := c.GetObjects(spaceID, opts.ObjectType)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get objects for space %s: %w", spaceID, err)
 	}
@@ -68,11 +111,10 @@ func (c *AnytypeClient) CreateOrUpdateNoteFromBook(spaceID string, book bookmark
 			if prop.Key == "description" {
 				if prop.Value == strconv.Itoa(book.ID) {
 					fmt.Println("Found a matching note!:", book.Title, prop.Value)
-					req := c.CreateBookUpdateRequest(book, obj)
+					req := c.CreateBookUpdateRequest(book, obj, content, opts)
 					updatedObject, err := c.UpdateObject(spaceID, obj.ID, req)
 					if err != nil {
-						fmt.Println("Failed to update object:", err)
-						return nil, nil // Currently fails to update
+						return nil, fmt.Errorf("failed to update object: %w", err)
 					}
 					return updatedObject, nil
 				}
@@ -82,7 +124,7 @@ func (c *AnytypeClient) CreateOrUpdateNoteFromBook(spaceID string, book bookmark
 		}
 	}
 
-	req := c.CreateBookObjectRequest(book, content)
+	req := c.CreateBookObjectRequest(book, content, opts)
 	createdObject, err := c.CreateObject(spaceID, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create object: %w", err)
@@ -47,8 +47,16 @@ type SelectValue struct {
 func (v SelectValue) Type() string { return "select" }
 
 type CreateObjectProperty struct {
-	Key   string `json:"key"`
-	Value string `json:"text"`
+	Key      string   `json:"key"`
+	Value    string   `json:"text,omitempty"`
+	URL      string   `json:"url,omitempty"`
+	Number   *float64 `json:"number,omitempty"`
+	Date     string   `json:"date,omitempty"`
+	Files    []string `json:"files,omitempty"`
+	Objects  []string `json:"objects,omitempty"`
+	Checkbox *bool    `json:"checkbox,omitempty"`
+	// MultiSelect is a pointer so an empty list can be sent to clear the property
+	MultiSelect *[]string `json:"multi_select,omitempty"`
 }
 
 type CreateObjectRequest struct {
@@ -60,27 +68,50 @@ type CreateObjectRequest struct {
 }
 
 type ObjectIcon struct {
-	Emoji  string `json:"emoji"`
+	Emoji  string `json:"emoji,omitempty"`
+	File   string `json:"file,omitempty"`
 	Format string `json:"format"`
 }
 
 // CreateBookObjectRequest creates a CreateObjectRequest for a book
-func (c *AnytypeClient) CreateBookObjectRequest(book bookmarks.ReadwiseBook, content string) CreateObjectRequest {
+func (c *AnytypeClient) CreateBookObjectRequest(book bookmarks.ReadwiseBook, content string, opts BookObjectOptions) CreateObjectRequest {
+	name := opts.Name
+	if name == "" {
+		name = book.DefaultName()
+	}
+
 	return CreateObjectRequest{
-		Name:    fmt.Sprintf("%s - %s [SYNC]", book.Title, book.Author),
-		TypeKey: strings.ToLower(c.config.ObjectType), // FIXME does this only happens for the bookmarks??
+		Name:    name,
+		TypeKey: strings.ToLower(opts.ObjectType), // FIXME does this only happens for the bookmarks??
 		Body:    content,
-		Icon: &ObjectIcon{
-			Emoji:  "📚",
-			Format: "emoji",
-		},
-		Properties: []CreateObjectProperty{
+		Icon:    opts.objectIcon(),
+		Properties: append([]CreateObjectProperty{
 			{
 				Key:   "description",
 				Value: strconv.Itoa(book.ID),
 			},
-		},
+		}, opts.properties()...),
+	}
+}
+
+// objectIcon returns the uploaded image icon if any, the emoji otherwise
+func (opts BookObjectOptions) objectIcon() *ObjectIcon {
+	if opts.IconFileID != "" {
+		return &ObjectIcon{File: opts.IconFileID, Format: "file"}
+	}
+	if opts.Icon != "" {
+		return &ObjectIcon{Emoji: opts.Icon, Format: "emoji"}
 	}
+	return nil
+}
+
+// properties returns the extra properties set on book objects
+func (opts BookObjectOptions) properties() []CreateObjectProperty {
+	var props []CreateObjectProperty
+	if opts.CoverFileID != "" && opts.CoverProperty != "" {
+		props = append(props, CreateObjectProperty{Key: opts.CoverProperty, Files: []string{opts.CoverFileID}})
+	}
+	return append(props, opts.Properties...)
 }
 
 func (c *AnytypeClient) CreateObject(spaceID string, req CreateObjectRequest) (*AnytypeCreateObjectResponseItem, error) {
@@ -92,7 +123,7 @@ func (c *AnytypeClient) CreateObject(spaceID string, req CreateObjectRequest) (*
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("anytype API returned status %d", resp.StatusCode)
+		return nil, decodeAnytypeError(resp)
 	}
 
 	var objResp AnytypeCreateObjectResponse
@@ -109,3 +140,16 @@ func (respObj *AnytypeCreateObjectResponseItem) toAnytypeObject() AnytypeObject
 		Name: respObj.Name,
 	}
 }
+
+// CreateNamedObject creates an empty object with just a name, like an author or a collection
+func (c *AnytypeClient) CreateNamedObject(spaceID string, objectType string, name string) (*AnytypeObject, error) {
+	createdObject, err := c.CreateObject(spaceID, CreateObjectRequest{
+		Name:    name,
+		TypeKey: strings.ToLower(objectType),
+	})
+	if err != nil {
+		return nil, err
+	}
+	object := createdObject.toAnytypeObject()
+	return &object, nil
+}
@@ -0,0 +1,35 @@
+package notes
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// ArchiveObject archives an object. Anytype moves deleted objects to the bin instead of removing them.
+func (c *AnytypeClient) ArchiveObject(spaceID string, objectID string) error {
+	endpoint := fmt.Sprintf("/v1/spaces/%s/objects/%s", spaceID, objectID)
+	resp, err := c.makeRequest("DELETE", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to archive object: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return decodeAnytypeError(resp)
+	}
+
+	return nil
+}
+
+// FlagObject sets a checkbox property on an object
+func (c *AnytypeClient) FlagObject(spaceID string, objectID string, propertyKey string) error {
+	checked := true
+	req := AnytypeUpdateObjectRequest{
+		Properties: []CreateObjectProperty{
+			{Key: propertyKey, Checkbox: &checked},
+		},
+	}
+
+	_, err := c.UpdateObject(spaceID, objectID, req)
+	return err
+}
@@ -0,0 +1,49 @@
+package notes
+
+import (
+	"anytype-readwise/core"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxErrorBodySize limits how much of an error response is read
+const maxErrorBodySize = 64 << 10
+
+type anytypeErrorResponse struct {
+	Object  string `json:"object"`
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// decodeAnytypeError turns an unsuccessful Anytype API response into a *core.APIError
+func decodeAnytypeError(resp *http.Response) error {
+	apiErr := &core.APIError{
+		Service:    "anytype",
+		StatusCode: resp.StatusCode,
+		RetryAfter: core.ParseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	var errResp anytypeErrorResponse
+	if err := json.Unmarshal(body, &errResp); err == nil && (errResp.Message != "" || errResp.Code != "") {
+		apiErr.Code = errResp.Code
+		apiErr.Message = errResp.Message
+	} else {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+
+	switch {
+	case errors.Is(apiErr, core.ErrUnauthorized):
+		apiErr.Hint = "Check that ANYTYPE_API_KEY is valid and the app is still authorized"
+	case errors.Is(apiErr, core.ErrServerUnavailable):
+		apiErr.Hint = "Make sure the Anytype desktop app is running"
+	case errors.Is(apiErr, core.ErrNotFound):
+		apiErr.Hint = "Check the configured space, type and template IDs"
+	}
+
+	return apiErr
+}
@@ -0,0 +1,46 @@
+package notes
+
+import (
+	"anytype-readwise/core"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestUploadImageFromURLLimit(t *testing.T) {
+	uploaded := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/announced.jpg":
+			w.Header().Set("Content-Length", strconv.Itoa(maxImageSize+1))
+			w.WriteHeader(http.StatusOK)
+		case "/streamed.jpg":
+			// Flushing before writing drops the length, as servers streaming their response do
+			w.(http.Flusher).Flush()
+			w.Write(bytes.Repeat([]byte{0}, maxImageSize+1))
+		case "/cover.jpg":
+			w.Write([]byte("image"))
+		default:
+			uploaded = true
+			w.Write([]byte(`{"file": {"id": "file"}}`))
+		}
+	}))
+	defer server.Close()
+	client := NewAnytypeClient("key", server.URL, "", &core.Config{})
+
+	for _, name := range []string{"announced.jpg", "streamed.jpg"} {
+		if _, err := client.UploadImageFromURL("space", server.URL+"/"+name); err == nil || !strings.Contains(err.Error(), "exceeds the limit") {
+			t.Errorf("got error %v for %s, want the limit exceeded", err, name)
+		}
+	}
+	if uploaded {
+		t.Error("an image over the limit was uploaded")
+	}
+
+	if fileID, err := client.UploadImageFromURL("space", server.URL+"/cover.jpg"); err != nil || fileID != "file" {
+		t.Errorf("got file %q and error %v, want the uploaded file", fileID, err)
+	}
+}
@@ -6,14 +6,26 @@ import (
 	"net/http"
 )
 
+// objectsPageSize is the number of objects requested per page
+const objectsPageSize = 100
+
 type AnytypeGetObjectsResponse struct {
-	Data []AnytypeGetObjectResponseItem `json:"data"`
+	Data       []AnytypeGetObjectResponseItem `json:"data"`
+	Pagination AnytypePagination              `json:"pagination"`
+}
+
+type AnytypePagination struct {
+	Total   int  `json:"total"`
+	Offset  int  `json:"offset"`
+	Limit   int  `json:"limit"`
+	HasMore bool `json:"has_more"`
 }
 
 type AnytypeGetObjectResponseItem struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
-	Type struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Archived bool   `json:"archived"`
+	Type     struct {
 		Name string `json:"name"`
 	} `json:"type"`
 	Properties []struct {
@@ -22,45 +34,67 @@ type AnytypeGetObjectResponseItem struct {
 	}
 }
 
-func (c *AnytypeClient) GetObjects(spaceID string, typeKey string) ([]AnytypeGetObjectResponseItem, error) {
-	// Get all the objects in the space (there's no way to filter)
-	endpoint := fmt.Sprintf("/v1/spaces/%s/objects", spaceID)
-	resp, err := c.makeRequest("GET", endpoint, nil)
+type AnytypeGetObjectResponse struct {
+	Object AnytypeGetObjectResponseItem `json:"object"`
+}
 
+// GetObject returns a single object by ID
+func (c *AnytypeClient) GetObject(spaceID string, objectID string) (*AnytypeGetObjectResponseItem, error) {
+	endpoint := fmt.Sprintf("/v1/spaces/%s/objects/%s", spaceID, objectID)
+	resp, err := c.makeRequest("GET", endpoint, nil)
 	if err != nil {
-
-		return nil, fmt.Errorf("failed to get objects: %w", err)
-
+		return nil, fmt.Errorf("failed to get object: %w", err)
 	}
-
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
+		return nil, decodeAnytypeError(resp)
+	}
 
-		return nil, fmt.Errorf("anytype API returned status %d", resp.StatusCode)
-
+	var objResp AnytypeGetObjectResponse
+	if err := json.NewDecoder(resp.Body).Decode(&objResp); err != nil {
+		return nil, fmt.Errorf("failed to decode object response: %w", err)
 	}
+	return &objResp.Object, nil
+}
 
-	var objsResp AnytypeGetObjectsResponse
+func (c *AnytypeClient) GetObjects(spaceID string, typeKey string) ([]AnytypeGetObjectResponseItem, error) {
+	// Get all the objects in the space (there's no way to filter)
+	var filteredData []AnytypeGetObjectResponseItem
+	offset := 0
 
-	if err := json.NewDecoder(resp.Body).Decode(&objsResp); err != nil {
+	for {
+		endpoint := fmt.Sprintf("/v1/spaces/%s/objects?offset=%d&limit=%d", spaceID, offset, objectsPageSize)
+		resp, err := c.makeRequest("GET", endpoint, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get objects: %w", err)
+		}
 
-		return nil, fmt.Errorf("failed to decode objects response: %w", err)
+		if resp.StatusCode != http.StatusOK {
+			err := decodeAnytypeError(resp)
+			resp.Body.Close()
+			return nil, err
+		}
 
-	}
+		var objsResp AnytypeGetObjectsResponse
+		err = json.NewDecoder(resp.Body).Decode(&objsResp)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode objects response: %w", err)
+		}
 
-	// Filter objects based on type.name matching typeKey
-	var filteredData []AnytypeGetObjectResponseItem
-	for _, item := range objsResp.Data {
-		if item.Type.Name == typeKey {
-			filteredData = append(filteredData, item)
+		// Filter objects based on type.name matching typeKey
+		for _, item := range objsResp.Data {
+			if item.Type.Name == typeKey {
+				filteredData = append(filteredData, item)
+			}
 		}
-	}
 
-	if len(filteredData) == 0 {
-		return nil, fmt.Errorf("no objects found with type %s", typeKey)
+		if !objsResp.Pagination.HasMore || len(objsResp.Data) == 0 {
+			break
+		}
+		offset += len(objsResp.Data)
 	}
 
 	return filteredData, nil
-
 }
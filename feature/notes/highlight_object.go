@@ -0,0 +1,95 @@
+package notes
+
+import (
+	"anytype-readwise/core"
+	"anytype-readwise/feature/bookmarks"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// highlightNameLength is the number of characters of the highlight text used as object name
+const highlightNameLength = 80
+
+// HighlightObjectOptions describes how a highlight is stored in Anytype
+type HighlightObjectOptions struct {
+	ObjectType   string
+	BookObjectID string
+	Properties   core.HighlightPropertyKeys
+	// TagProperty and TagIDs set the tags of the highlight, when TagProperty is not empty
+	TagProperty string
+	TagIDs      []string
+}
+
+// HighlightDescription is the description used to find the object of a highlight
+func HighlightDescription(highlightID int) string {
+	return fmt.Sprintf("highlight:%d", highlightID)
+}
+
+// ObjectLink returns a deep link opening an object in the Anytype app
+func ObjectLink(spaceID, objectID string) string {
+	return fmt.Sprintf("anytype://object?objectId=%s&spaceId=%s", objectID, spaceID)
+}
+
+// CreateHighlightObjectRequest creates a CreateObjectRequest for a single highlight
+func (c *AnytypeClient) CreateHighlightObjectRequest(highlight bookmarks.Highlight, opts HighlightObjectOptions) CreateObjectRequest {
+	body := "> " + strings.ReplaceAll(highlight.Text, "\n", "\n> ") + "\n"
+	if highlight.Note != "" {
+		body += "\n" + highlight.Note + "\n"
+	}
+
+	location := float64(highlight.Location)
+	props := []CreateObjectProperty{
+		{Key: "description", Value: HighlightDescription(highlight.ID)},
+		{Key: opts.Properties.Text, Value: highlight.Text},
+		{Key: opts.Properties.Location, Number: &location},
+	}
+	if highlight.Note != "" {
+		props = append(props, CreateObjectProperty{Key: opts.Properties.Note, Value: highlight.Note})
+	}
+	if highlight.Color != "" {
+		props = append(props, CreateObjectProperty{Key: opts.Properties.Color, Value: highlight.Color})
+	}
+	if !highlight.HighlightedAt.IsZero() {
+		props = append(props, CreateObjectProperty{Key: opts.Properties.HighlightedAt, Date: highlight.HighlightedAt.Format(time.RFC3339)})
+	}
+	if opts.BookObjectID != "" {
+		props = append(props, CreateObjectProperty{Key: opts.Properties.Book, Objects: []string{opts.BookObjectID}})
+	}
+	if opts.TagProperty != "" {
+		props = append(props, MultiSelectProperty(opts.TagProperty, opts.TagIDs))
+	}
+
+	return CreateObjectRequest{
+		Name:       highlightObjectName(highlight.Text),
+		TypeKey:    strings.ToLower(opts.ObjectType),
+		Body:       body,
+		Icon:       &ObjectIcon{Emoji: "🖍️", Format: "emoji"},
+		Properties: props,
+	}
+}
+
+// CreateOrUpdateHighlightObject creates the object of a highlight, or updates it when objectID is known
+func (c *AnytypeClient) CreateOrUpdateHighlightObject(spaceID string, objectID string, highlight bookmarks.Highlight, opts HighlightObjectOptions) (*AnytypeObject, error) {
+	req := c.CreateHighlightObjectRequest(highlight, opts)
+
+	if objectID != "" {
+		return c.UpdateObject(spaceID, objectID, AnytypeUpdateObjectRequest{
+			Name:       req.Name,
+			Body:       req.Body,
+			Properties: req.Properties,
+		})
+	}
+
+	createdObject, err := c.CreateObject(spaceID, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create highlight object: %w", err)
+	}
+	object := createdObject.toAnytypeObject()
+	return &object, nil
+}
+
+// highlightObjectName uses the beginning of the highlight text as object name
+func highlightObjectName(text string) string {
+	return core.Truncate(strings.Join(strings.Fields(text), " "), highlightNameLength)
+}
@@ -0,0 +1,265 @@
+package notes
+
+import (
+	"anytype-readwise/core"
+	"anytype-readwise/feature/bookmarks"
+	"archive/zip"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ImportBundle implements the Sink interface by collecting the rendered books into a zip
+// that the Anytype Markdown importer reads. Each book is a file with front matter linking to
+// the pages of its authors, category and tags, and an index file links to every book.
+// The zip is written by Close.
+type ImportBundle struct {
+	path  string
+	index string
+	files map[string]bundleFile
+}
+
+// bundleFile is a book file of the bundle
+type bundleFile struct {
+	book    bookmarks.ReadwiseBook
+	name    string
+	content string
+	// pages are the author, category and tag pages the book links to
+	pages []string
+}
+
+// NewImportBundle creates a new ImportBundle written to the given zip file,
+// with an index named after indexName
+func NewImportBundle(path string, indexName string) *ImportBundle {
+	if indexName == "" {
+		indexName = "Readwise Library"
+	}
+	return &ImportBundle{path: path, index: indexName, files: make(map[string]bundleFile)}
+}
+
+// GetSpaceID returns the bundle path, the only space of the bundle
+func (b *ImportBundle) GetSpaceID() (string, error) {
+	return b.path, nil
+}
+
+// CreateOrUpdateNoteFromBook adds the file of a book to the bundle
+func (b *ImportBundle) CreateOrUpdateNoteFromBook(spaceID string, book bookmarks.ReadwiseBook, content string, opts BookObjectOptions) (*AnytypeObject, error) {
+	name := opts.Name
+	if name == "" {
+		name = book.DefaultName()
+	}
+
+	category := "Uncategorized"
+	if book.Category != "" {
+		category = core.TitleCase(book.Category)
+	}
+	// Anytype names the imported objects after their file
+	file := path.Join(bundleFileName(category), bundleFileName(name)+".md")
+	if _, taken := b.files[file]; taken {
+		file = path.Join(bundleFileName(category), fmt.Sprintf("%s (%d).md", bundleFileName(name), book.ID))
+	}
+
+	var sb strings.Builder
+	sb.WriteString("---\n")
+	// The API sync finds the imported objects by the book ID in their description
+	fmt.Fprintf(&sb, "description: %s\n", yamlString(strconv.Itoa(book.ID)))
+	for _, field := range bookFrontMatter(book, opts) {
+		if field.key != "description" {
+			fmt.Fprintf(&sb, "%s: %s\n", field.key, field.value)
+		}
+	}
+	sb.WriteString("---\n\n")
+	sb.WriteString(strings.TrimRight(content, "\n"))
+	sb.WriteString("\n")
+
+	authors := opts.Authors
+	if len(authors) == 0 && book.Author != "" {
+		authors = []string{book.Author}
+	}
+	var pages []string
+	for _, author := range authors {
+		pages = append(pages, path.Join("Authors", bundleFileName(author)+".md"))
+	}
+	pages = append(pages, path.Join("Categories", bundleFileName(category)+".md"))
+	for _, tag := range book.AllTags() {
+		pages = append(pages, path.Join("Tags", bundleFileName(tag.Name)+".md"))
+	}
+
+	b.files[file] = bundleFile{book: book, name: name, content: sb.String(), pages: pages}
+	return &AnytypeObject{ID: file, Name: name}, nil
+}
+
+// ArchiveObject removes a book from the bundle
+func (b *ImportBundle) ArchiveObject(spaceID string, objectID string) error {
+	if _, ok := b.files[objectID]; !ok {
+		return fmt.Errorf("%w: %s", core.ErrNotFound, objectID)
+	}
+	delete(b.files, objectID)
+	return nil
+}
+
+// FlagObject does nothing, the books deleted from the source are not part of a new bundle
+func (b *ImportBundle) FlagObject(spaceID string, objectID string, propertyKey string) error {
+	return nil
+}
+
+// Close writes the bundle with its pages and index
+func (b *ImportBundle) Close() error {
+	out, err := os.Create(b.path)
+	if err != nil {
+		return fmt.Errorf("failed to create import bundle: %w", err)
+	}
+	defer out.Close()
+
+	files := make([]string, 0, len(b.files))
+	for file := range b.files {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	// Pages list the books linking to them
+	pages := make(map[string][]string)
+	for _, file := range files {
+		for _, page := range b.files[file].pages {
+			pages[page] = append(pages[page], file)
+		}
+	}
+	pageFiles := make([]string, 0, len(pages))
+	for page := range pages {
+		pageFiles = append(pageFiles, page)
+	}
+	sort.Strings(pageFiles)
+
+	archive := zip.NewWriter(out)
+	for _, file := range files {
+		if err := writeZipFile(archive, file, b.files[file].content+b.relatedContent(file, pages)); err != nil {
+			return err
+		}
+	}
+	for _, page := range pageFiles {
+		if err := writeZipFile(archive, page, b.pageContent(page, pages[page])); err != nil {
+			return err
+		}
+	}
+	if err := writeZipFile(archive, bundleFileName(b.index)+".md", b.indexContent(files)); err != nil {
+		return err
+	}
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to write import bundle: %w", err)
+	}
+	fmt.Printf("Wrote %d books to import bundle %s\n", len(files), b.path)
+	return out.Close()
+}
+
+// indexContent lists the books by category, linking to their files
+func (b *ImportBundle) indexContent(files []string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n", b.index)
+
+	category := ""
+	for _, file := range files {
+		if dir := path.Dir(file); dir != category {
+			category = dir
+			fmt.Fprintf(&sb, "\n## %s\n\n", category)
+		}
+		book := b.files[file]
+		fmt.Fprintf(&sb, "- %s", bundleLink("", file, book.name))
+		if book.book.Author != "" {
+			fmt.Fprintf(&sb, " by %s", book.book.Author)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// relatedContent links a book to its pages and to the other books of its authors
+func (b *ImportBundle) relatedContent(file string, pages map[string][]string) string {
+	book := b.files[file]
+	kinds := make(map[string][]string)
+	var sameAuthor []string
+	seen := map[string]bool{file: true}
+	for _, page := range book.pages {
+		kind := path.Dir(page)
+		kinds[kind] = append(kinds[kind], bundleLink(file, page, pageTitle(page)))
+		if kind != "Authors" {
+			continue
+		}
+		for _, other := range pages[page] {
+			if !seen[other] {
+				seen[other] = true
+				sameAuthor = append(sameAuthor, bundleLink(file, other, b.files[other].name))
+			}
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\n## Related\n\n")
+	for _, kind := range []string{"Authors", "Categories", "Tags"} {
+		if len(kinds[kind]) > 0 {
+			fmt.Fprintf(&sb, "- %s: %s\n", kind, strings.Join(kinds[kind], ", "))
+		}
+	}
+	if len(sameAuthor) > 0 {
+		fmt.Fprintf(&sb, "- Same author: %s\n", strings.Join(sameAuthor, ", "))
+	}
+	return sb.String()
+}
+
+// pageContent lists the books linking to an author, category or tag page
+func (b *ImportBundle) pageContent(page string, files []string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n\n", pageTitle(page))
+	for _, file := range files {
+		fmt.Fprintf(&sb, "- %s\n", bundleLink(page, file, b.files[file].name))
+	}
+	return sb.String()
+}
+
+// pageTitle returns the title of a page, its file name
+func pageTitle(page string) string {
+	return strings.TrimSuffix(path.Base(page), ".md")
+}
+
+// bundleLink returns a markdown link from a file of the bundle to another.
+// Anytype turns relative links to imported files into links to their objects.
+func bundleLink(from string, to string, title string) string {
+	if path.Dir(from) != "." {
+		to = "../" + to
+	}
+	title = strings.NewReplacer("[", `\[`, "]", `\]`).Replace(title)
+	return fmt.Sprintf("[%s](%s)", title, (&url.URL{Path: to}).EscapedPath())
+}
+
+func writeZipFile(archive *zip.Writer, name string, content string) error {
+	w, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return fmt.Errorf("failed to add %s to import bundle: %w", name, err)
+	}
+	if _, err := w.Write([]byte(content)); err != nil {
+		return fmt.Errorf("failed to add %s to import bundle: %w", name, err)
+	}
+	return nil
+}
+
+// bundleFileName replaces the characters file systems don't accept in names
+func bundleFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < ' ' {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(strings.Trim(name, "."))
+	if runes := []rune(name); len(runes) > 120 {
+		name = strings.TrimSpace(string(runes[:120]))
+	}
+	if name == "" {
+		return "Untitled"
+	}
+	return name
+}
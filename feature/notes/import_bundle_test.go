@@ -0,0 +1,83 @@
+package notes
+
+import (
+	"anytype-readwise/feature/bookmarks"
+	"archive/zip"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImportBundleLinks(t *testing.T) {
+	bundlePath := filepath.Join(t.TempDir(), "library.zip")
+	bundle := NewImportBundle(bundlePath, "")
+
+	deepWork := bookmarks.ReadwiseBook{ID: 1, Title: "Deep Work", Author: "Cal Newport", Category: "éditions", Tags: []bookmarks.Tag{{Name: "focus"}}}
+	if _, err := bundle.CreateOrUpdateNoteFromBook(bundlePath, deepWork, "Body", BookObjectOptions{Authors: []string{"Cal Newport"}}); err != nil {
+		t.Fatal(err)
+	}
+	soGood := bookmarks.ReadwiseBook{ID: 2, Title: "So Good", Author: "Cal Newport", Category: "books"}
+	if _, err := bundle.CreateOrUpdateNoteFromBook(bundlePath, soGood, "Body", BookObjectOptions{Name: "So Good"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := bundle.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	files := readZip(t, bundlePath)
+	book, ok := files["Éditions/Deep Work - Cal Newport [SYNC].md"]
+	if !ok {
+		t.Fatalf("got files %v, want the book under the title-cased category with the default name", keys(files))
+	}
+	for _, link := range []string{
+		"[Cal Newport](../Authors/Cal%20Newport.md)",
+		"[Éditions](../Categories/%C3%89ditions.md)",
+		"[focus](../Tags/focus.md)",
+		"[So Good](../Books/So%20Good.md)",
+	} {
+		if !strings.Contains(book, link) {
+			t.Errorf("book file is missing the link %s:\n%s", link, book)
+		}
+	}
+
+	author := files["Authors/Cal Newport.md"]
+	if !strings.Contains(author, "(../Books/So%20Good.md)") || !strings.Contains(author, "(../%C3%89ditions/Deep%20Work%20-%20Cal%20Newport%20%5BSYNC%5D.md)") {
+		t.Errorf("author page doesn't link both books:\n%s", author)
+	}
+	if _, ok := files["Readwise Library.md"]; !ok {
+		t.Errorf("got files %v, want the index", keys(files))
+	}
+}
+
+func readZip(t *testing.T, path string) map[string]string {
+	t.Helper()
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+
+	files := make(map[string]string)
+	for _, file := range archive.File {
+		r, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[file.Name] = string(content)
+	}
+	return files
+}
+
+func keys(files map[string]string) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,42 @@
+package notes
+
+import (
+	"fmt"
+	"net/http"
+)
+
+type AddObjectsToListRequest struct {
+	Objects []string `json:"objects"`
+}
+
+// AddObjectsToList adds objects to a collection through the lists API
+func (c *AnytypeClient) AddObjectsToList(spaceID string, listID string, objectIDs []string) error {
+	endpoint := fmt.Sprintf("/v1/spaces/%s/lists/%s/objects", spaceID, listID)
+	resp, err := c.makeRequest("POST", endpoint, AddObjectsToListRequest{Objects: objectIDs})
+	if err != nil {
+		return fmt.Errorf("failed to add objects to list: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return decodeAnytypeError(resp)
+	}
+
+	return nil
+}
+
+// RemoveObjectFromList removes an object from a collection through the lists API
+func (c *AnytypeClient) RemoveObjectFromList(spaceID string, listID string, objectID string) error {
+	endpoint := fmt.Sprintf("/v1/spaces/%s/lists/%s/objects/%s", spaceID, listID, objectID)
+	resp, err := c.makeRequest("DELETE", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to remove object from list: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return decodeAnytypeError(resp)
+	}
+
+	return nil
+}
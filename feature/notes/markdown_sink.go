@@ -0,0 +1,321 @@
+package notes
+
+import (
+	"anytype-readwise/core"
+	"anytype-readwise/feature/bookmarks"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// MarkdownSink implements the Sink interface by writing every book to <dir>/<category>/<slug>.md,
+// with YAML front matter carrying the Readwise ID and the metadata of the book
+type MarkdownSink struct {
+	dir string
+	// files maps the book IDs to their file, relative to dir, read from the front matter of the existing files
+	files map[int]string
+}
+
+// frontMatterField is a key and its YAML value
+type frontMatterField struct {
+	key   string
+	value string
+}
+
+// NewMarkdownSink creates a new MarkdownSink writing to the given directory
+func NewMarkdownSink(dir string) *MarkdownSink {
+	return &MarkdownSink{dir: dir}
+}
+
+// GetSpaceID returns the output directory, the only space of the sink
+func (m *MarkdownSink) GetSpaceID() (string, error) {
+	return m.dir, nil
+}
+
+// CreateOrUpdateNoteFromBook writes the file of a book, in place when the book was already written
+func (m *MarkdownSink) CreateOrUpdateNoteFromBook(spaceID string, book bookmarks.ReadwiseBook, content string, opts BookObjectOptions) (*AnytypeObject, error) {
+	if err := m.loadFiles(); err != nil {
+		return nil, err
+	}
+
+	name, ok := m.files[book.ID]
+	if !ok && opts.ObjectID != "" && m.fileExists(opts.ObjectID) {
+		// The file of the same book written under another ID, as by a backfill from another source
+		name = opts.ObjectID
+		for bookID, existing := range m.files {
+			if existing == name {
+				delete(m.files, bookID)
+			}
+		}
+	} else if !ok {
+		name = m.newFileName(book)
+	}
+	path := filepath.Join(m.dir, filepath.FromSlash(name))
+
+	var b strings.Builder
+	b.WriteString("---\n")
+	for _, field := range bookFrontMatter(book, opts) {
+		fmt.Fprintf(&b, "%s: %s\n", field.key, field.value)
+	}
+	b.WriteString("---\n\n")
+	b.WriteString(strings.TrimRight(content, "\n"))
+	b.WriteString("\n")
+
+	// Unchanged files are left alone, so their modification time stays meaningful
+	existing, err := os.ReadFile(path)
+	if err == nil && string(existing) == b.String() {
+		return &AnytypeObject{ID: name, Name: book.Title}, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create directory for %s: %w", name, err)
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	m.files[book.ID] = name
+	return &AnytypeObject{ID: name, Name: book.Title}, nil
+}
+
+// ArchiveObject deletes the file of a book, and its category directory once empty
+func (m *MarkdownSink) ArchiveObject(spaceID string, objectID string) error {
+	path := filepath.Join(m.dir, filepath.FromSlash(objectID))
+	if err := os.Remove(path); os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", core.ErrNotFound, objectID)
+	} else if err != nil {
+		return fmt.Errorf("failed to delete %s: %w", objectID, err)
+	}
+	os.Remove(filepath.Dir(path))
+
+	for bookID, name := range m.files {
+		if name == objectID {
+			delete(m.files, bookID)
+		}
+	}
+	return nil
+}
+
+// FlagObject sets a property to true in the front matter of a file
+func (m *MarkdownSink) FlagObject(spaceID string, objectID string, propertyKey string) error {
+	path := filepath.Join(m.dir, filepath.FromSlash(objectID))
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", core.ErrNotFound, objectID)
+	} else if err != nil {
+		return fmt.Errorf("failed to read %s: %w", objectID, err)
+	}
+
+	lines := strings.Split(string(content), "\n")
+	if len(lines) == 0 || lines[0] != "---" {
+		return fmt.Errorf("%w: %s has no front matter", core.ErrValidation, objectID)
+	}
+	for i := 1; i < len(lines); i++ {
+		if strings.HasPrefix(lines[i], propertyKey+":") {
+			lines[i] = propertyKey + ": true"
+			break
+		}
+		if lines[i] == "---" {
+			lines = append(lines[:i], append([]string{propertyKey + ": true"}, lines[i:]...)...)
+			break
+		}
+	}
+
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", objectID, err)
+	}
+	return nil
+}
+
+// loadFiles finds the files written before from the readwise_id of their front matter
+func (m *MarkdownSink) loadFiles() error {
+	if m.files != nil {
+		return nil
+	}
+	m.files = make(map[int]string)
+
+	err := filepath.WalkDir(m.dir, func(path string, entry fs.DirEntry, err error) error {
+		if os.IsNotExist(err) && path == m.dir {
+			return filepath.SkipDir
+		}
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || filepath.Ext(path) != ".md" {
+			return nil
+		}
+
+		bookID, err := readFrontMatterID(path)
+		if err != nil {
+			return err
+		}
+		if bookID != 0 {
+			name, _ := filepath.Rel(m.dir, path)
+			m.files[bookID] = filepath.ToSlash(name)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to scan output directory: %w", err)
+	}
+	return nil
+}
+
+// readFrontMatterID returns the readwise_id of the front matter of a file, or 0
+func readFrontMatterID(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	if !scanner.Scan() || scanner.Text() != "---" {
+		return 0, nil
+	}
+	for scanner.Scan() && scanner.Text() != "---" {
+		if value, ok := strings.CutPrefix(scanner.Text(), "readwise_id:"); ok {
+			id, _ := strconv.Atoi(strings.TrimSpace(value))
+			return id, nil
+		}
+	}
+	return 0, scanner.Err()
+}
+
+func (m *MarkdownSink) fileExists(name string) bool {
+	_, err := os.Stat(filepath.Join(m.dir, filepath.FromSlash(name)))
+	return err == nil
+}
+
+// newFileName picks the file of a new book, from its category and title
+func (m *MarkdownSink) newFileName(book bookmarks.ReadwiseBook) string {
+	category := slugify(book.Category)
+	if category == "" {
+		category = "uncategorized"
+	}
+	slug := slugify(book.Title)
+	if slug == "" {
+		slug = "book"
+	}
+
+	name := category + "/" + slug + ".md"
+	taken := func(name string) bool {
+		for _, existing := range m.files {
+			if existing == name {
+				return true
+			}
+		}
+		return m.fileExists(name)
+	}
+	// Books with the same title are told apart by their ID
+	if taken(name) {
+		name = fmt.Sprintf("%s/%s-%d.md", category, slug, book.ID)
+	}
+	return name
+}
+
+// bookFrontMatter lists the front matter of a book, followed by the mapped properties
+func bookFrontMatter(book bookmarks.ReadwiseBook, opts BookObjectOptions) []frontMatterField {
+	var fields []frontMatterField
+	set := func(key, value string) {
+		for i := range fields {
+			if fields[i].key == key {
+				fields[i].value = value
+				return
+			}
+		}
+		fields = append(fields, frontMatterField{key, value})
+	}
+	text := func(key, value string) {
+		if value != "" {
+			set(key, yamlString(value))
+		}
+	}
+	date := func(key string, value time.Time) {
+		if !value.IsZero() {
+			set(key, value.UTC().Format(time.RFC3339))
+		}
+	}
+
+	text("title", book.DisplayTitle())
+	text("author", book.Author)
+	text("category", book.Category)
+	text("source", book.Source)
+	set("readwise_id", strconv.Itoa(book.ID))
+	text("readwise_url", book.ReadwiseURL)
+	text("source_url", book.SourceURL)
+	text("cover_image_url", book.CoverImageURL)
+	text("asin", book.ASIN)
+	text("isbn", book.ISBN)
+	text("doi", book.DOI)
+	text("citation_key", book.CitationKey)
+	if book.Year != 0 {
+		set("year", strconv.Itoa(book.Year))
+	}
+	text("language", book.Language)
+	if tags := book.AllTags(); len(tags) > 0 {
+		names := make([]string, 0, len(tags))
+		for _, tag := range tags {
+			names = append(names, yamlString(tag.Name))
+		}
+		set("tags", "["+strings.Join(names, ", ")+"]")
+	}
+	set("num_highlights", strconv.Itoa(book.NumHighlights))
+	date("last_highlight_at", book.LastHighlight)
+	date("updated", book.Updated)
+
+	for _, prop := range opts.Properties {
+		switch {
+		case prop.Key == "readwise_id":
+			// The sink finds the file of a book again by its ID, a mapped property can't replace it
+			continue
+		case prop.URL != "":
+			set(prop.Key, yamlString(prop.URL))
+		case prop.Number != nil:
+			set(prop.Key, strconv.FormatFloat(*prop.Number, 'f', -1, 64))
+		case prop.Date != "":
+			set(prop.Key, prop.Date)
+		case prop.Checkbox != nil:
+			set(prop.Key, strconv.FormatBool(*prop.Checkbox))
+		case prop.Value != "":
+			set(prop.Key, yamlString(prop.Value))
+		}
+	}
+	return fields
+}
+
+// yamlString quotes a string, JSON strings being valid YAML
+func yamlString(value string) string {
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(value)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// slugify keeps the lowercased letters and digits of a title, separated by dashes
+func slugify(value string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(value) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+		if b.Len() >= 80 {
+			break
+		}
+	}
+	return b.String()
+}
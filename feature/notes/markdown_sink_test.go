@@ -0,0 +1,22 @@
+package notes
+
+import (
+	"anytype-readwise/feature/bookmarks"
+	"testing"
+)
+
+func TestBookFrontMatterReservesID(t *testing.T) {
+	opts := BookObjectOptions{Properties: []CreateObjectProperty{{Key: "readwise_id", Value: "other"}, {Key: "rating", Value: "5"}}}
+	fields := bookFrontMatter(bookmarks.ReadwiseBook{ID: 42, Title: "Deep Work"}, opts)
+
+	values := make(map[string]string)
+	for _, field := range fields {
+		values[field.key] = field.value
+	}
+	if values["readwise_id"] != "42" {
+		t.Errorf("got readwise_id %s, want the book ID", values["readwise_id"])
+	}
+	if values["rating"] != `"5"` {
+		t.Errorf("got rating %s, want the mapped property", values["rating"])
+	}
+}
@@ -0,0 +1,20 @@
+package notes
+
+import (
+	"anytype-readwise/feature/bookmarks"
+)
+
+// Sink is an interface for writing the rendered books, to Anytype or elsewhere
+type Sink interface {
+	// GetSpaceID returns the space books are written to when none is configured
+	GetSpaceID() (string, error)
+
+	// CreateOrUpdateNoteFromBook writes the rendered content of a book, creating its object or updating the existing one
+	CreateOrUpdateNoteFromBook(spaceID string, book bookmarks.ReadwiseBook, content string, opts BookObjectOptions) (*AnytypeObject, error)
+
+	// ArchiveObject removes the object of a book deleted from the source
+	ArchiveObject(spaceID string, objectID string) error
+
+	// FlagObject sets a checkbox property on an object
+	FlagObject(spaceID string, objectID string, propertyKey string) error
+}
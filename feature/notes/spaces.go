@@ -44,7 +44,7 @@ func (c *AnytypeClient) GetSpaces() ([]AnytypeSpace, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("anytype API returned status %d", resp.StatusCode)
+		return nil, decodeAnytypeError(resp)
 	}
 
 	var spacesResp AnytypeSpacesResponse
@@ -0,0 +1,133 @@
+package notes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// listPageSize is the number of properties or tags requested at once
+const listPageSize = 1000
+
+type AnytypeProperty struct {
+	ID     string `json:"id"`
+	Key    string `json:"key"`
+	Name   string `json:"name"`
+	Format string `json:"format"`
+}
+
+type AnytypePropertiesResponse struct {
+	Data []AnytypeProperty `json:"data"`
+}
+
+type AnytypeTag struct {
+	ID    string `json:"id"`
+	Key   string `json:"key"`
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+type AnytypeTagsResponse struct {
+	Data []AnytypeTag `json:"data"`
+}
+
+type AnytypeTagResponse struct {
+	Tag AnytypeTag `json:"tag"`
+}
+
+type AnytypeTagRequest struct {
+	Name  string `json:"name"`
+	Color string `json:"color,omitempty"`
+}
+
+// GetProperty returns the property with the given key
+func (c *AnytypeClient) GetProperty(spaceID string, key string) (*AnytypeProperty, error) {
+	endpoint := fmt.Sprintf("/v1/spaces/%s/properties?limit=%d", spaceID, listPageSize)
+	resp, err := c.makeRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get properties: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeAnytypeError(resp)
+	}
+
+	var propsResp AnytypePropertiesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&propsResp); err != nil {
+		return nil, fmt.Errorf("failed to decode properties response: %w", err)
+	}
+
+	for _, prop := range propsResp.Data {
+		if prop.Key == key {
+			return &prop, nil
+		}
+	}
+	return nil, fmt.Errorf("property %s not found in space %s", key, spaceID)
+}
+
+// GetTags returns the tags (options) of a select or multi-select property
+func (c *AnytypeClient) GetTags(spaceID string, propertyID string) ([]AnytypeTag, error) {
+	endpoint := fmt.Sprintf("/v1/spaces/%s/properties/%s/tags?limit=%d", spaceID, propertyID, listPageSize)
+	resp, err := c.makeRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeAnytypeError(resp)
+	}
+
+	var tagsResp AnytypeTagsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tagsResp); err != nil {
+		return nil, fmt.Errorf("failed to decode tags response: %w", err)
+	}
+
+	return tagsResp.Data, nil
+}
+
+// CreateTag adds a tag to a select or multi-select property
+func (c *AnytypeClient) CreateTag(spaceID string, propertyID string, name string) (*AnytypeTag, error) {
+	endpoint := fmt.Sprintf("/v1/spaces/%s/properties/%s/tags", spaceID, propertyID)
+	resp, err := c.makeRequest("POST", endpoint, AnytypeTagRequest{Name: name, Color: "grey"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tag: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, decodeAnytypeError(resp)
+	}
+
+	var tagResp AnytypeTagResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tagResp); err != nil {
+		return nil, fmt.Errorf("failed to decode tag response: %w", err)
+	}
+
+	return &tagResp.Tag, nil
+}
+
+// RenameTag changes the name of an existing tag
+func (c *AnytypeClient) RenameTag(spaceID string, propertyID string, tagID string, name string) error {
+	endpoint := fmt.Sprintf("/v1/spaces/%s/properties/%s/tags/%s", spaceID, propertyID, tagID)
+	resp, err := c.makeRequest("PATCH", endpoint, AnytypeTagRequest{Name: name})
+	if err != nil {
+		return fmt.Errorf("failed to rename tag: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return decodeAnytypeError(resp)
+	}
+
+	return nil
+}
+
+// MultiSelectProperty sets a multi-select property, clearing it when tagIDs is empty
+func MultiSelectProperty(key string, tagIDs []string) CreateObjectProperty {
+	if tagIDs == nil {
+		tagIDs = []string{}
+	}
+	return CreateObjectProperty{Key: key, MultiSelect: &tagIDs}
+}
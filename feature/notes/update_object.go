@@ -4,30 +4,45 @@ import (
 	"anytype-readwise/feature/bookmarks"
 	"encoding/json"
 	"fmt"
+	"net/http"
 )
 
 type AnytypeUpdateObjectRequest struct {
-	Name string `json:"name"`
-	Body string `json:"body"`
+	Name       string                 `json:"name,omitempty"`
+	Body       string                 `json:"body,omitempty"`
+	Icon       *ObjectIcon            `json:"icon,omitempty"`
+	Properties []CreateObjectProperty `json:"properties,omitempty"`
 }
 
-func (c *AnytypeClient) CreateBookUpdateRequest(book bookmarks.ReadwiseBook, anytypeObject AnytypeGetObjectResponseItem) AnytypeUpdateObjectRequest {
+// CreateBookUpdateRequest creates an AnytypeUpdateObjectRequest for a book that was already synced
+func (c *AnytypeClient) CreateBookUpdateRequest(book bookmarks.ReadwiseBook, anytypeObject AnytypeGetObjectResponseItem, content string, opts BookObjectOptions) AnytypeUpdateObjectRequest {
+	req := AnytypeUpdateObjectRequest{
+		Body:       content, // FIXME The API may ignore body updates depending on the Anytype version
+		Icon:       opts.objectIcon(),
+		Properties: opts.properties(),
+	}
 
-	return AnytypeUpdateObjectRequest{
-		Name: anytypeObject.Name + "UPDATED!",
-		Body: book.Author, // FIXME There's no way to update the body of a note currently
+	// Rename the object when the naming pattern produces a different name
+	if opts.Name != "" && opts.Name != anytypeObject.Name {
+		fmt.Printf("Renaming object %q to %q\n", anytypeObject.Name, opts.Name)
+		req.Name = opts.Name
 	}
 
+	return req
 }
 
 func (c *AnytypeClient) UpdateObject(spaceID string, objectID string, req AnytypeUpdateObjectRequest) (*AnytypeObject, error) {
 	endpoint := fmt.Sprintf("/v1/spaces/%s/objects/%s", spaceID, objectID)
-	resp, err := c.makeRequest("POST", endpoint, req)
+	resp, err := c.makeRequest("PATCH", endpoint, req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create object: %w", err)
+		return nil, fmt.Errorf("failed to update object: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeAnytypeError(resp)
+	}
+
 	var objResp AnytypeCreateObjectResponse
 	if err := json.NewDecoder(resp.Body).Decode(&objResp); err != nil {
 		return nil, fmt.Errorf("failed to decode object response: %w", err)
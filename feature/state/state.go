@@ -0,0 +1,191 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// State is the local sync state persisted between runs
+type State struct {
+	path string
+
+	// Covers maps a space ID to the uploaded file ID of every cover image URL
+	Covers map[string]map[string]string `json:"covers"`
+
+	// Books maps every synced Readwise book ID to its Anytype object
+	Books map[int]*BookRecord `json:"books"`
+
+	// Authors maps a space ID to the object ID of every normalized author name
+	Authors map[string]map[string]string `json:"authors"`
+
+	// Collections maps a space ID to the object ID of every collection by name
+	Collections map[string]map[string]string `json:"collections"`
+
+	// Tags maps a space ID to the Anytype tag ID of every Readwise tag
+	Tags map[string]map[string]string `json:"tags"`
+
+	// Highlights maps every synced highlight ID to its text, note and previous versions
+	Highlights map[int]*HighlightRecord `json:"highlights"`
+}
+
+// BookRecord is what the syncer remembers about a synced book
+type BookRecord struct {
+	// Source is the provider the book came from, deletions are only detected within a source
+	Source       string `json:"source,omitempty"`
+	Title        string `json:"title"`
+	SpaceID      string `json:"space_id"`
+	ObjectID     string `json:"object_id"`
+	HighlightIDs []int  `json:"highlight_ids"`
+	// HighlightObjects maps highlight IDs to their objects when highlights are synced as objects
+	HighlightObjects map[int]string `json:"highlight_objects,omitempty"`
+	// CollectionID is the collection the book object was added to
+	CollectionID string `json:"collection_id,omitempty"`
+	// Deleted is set once the deletion of the source has been handled
+	Deleted bool `json:"deleted,omitempty"`
+	// MatchKeys identify the book across sources, to find the objects of a backfill from another source
+	MatchKeys []string `json:"match_keys,omitempty"`
+}
+
+// HighlightRecord is what the syncer remembers about a highlight to detect its edits
+type HighlightRecord struct {
+	BookID  int       `json:"book_id"`
+	Text    string    `json:"text"`
+	Note    string    `json:"note,omitempty"`
+	Updated time.Time `json:"updated"`
+	// Location and EndLocation find the highlight again when its ID changed with its text,
+	// in the sources of core.TextDerivedIDSources
+	Location    int `json:"location,omitempty"`
+	EndLocation int `json:"end_location,omitempty"`
+	// EditedAt is when the text or note was last edited, zero when it never was
+	EditedAt time.Time `json:"edited_at"`
+	// Versions are the previous texts and notes, oldest first
+	Versions []HighlightVersion `json:"versions,omitempty"`
+}
+
+// HighlightVersion is a previous text and note of a highlight
+type HighlightVersion struct {
+	Text string `json:"text"`
+	Note string `json:"note,omitempty"`
+	// Updated is when the version was written in the source
+	Updated time.Time `json:"updated"`
+}
+
+// Load reads the state file at path. A missing file results in an empty state,
+// and an empty path in a state that is never saved.
+func Load(path string) (*State, error) {
+	s := &State{path: path}
+
+	content, err := os.ReadFile(path)
+	if path == "" {
+		content, err = nil, os.ErrNotExist
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(content, s); err != nil {
+			return nil, fmt.Errorf("failed to parse state file %s: %w", path, err)
+		}
+	}
+
+	if s.Covers == nil {
+		s.Covers = make(map[string]map[string]string)
+	}
+	if s.Books == nil {
+		s.Books = make(map[int]*BookRecord)
+	}
+	if s.Authors == nil {
+		s.Authors = make(map[string]map[string]string)
+	}
+	if s.Collections == nil {
+		s.Collections = make(map[string]map[string]string)
+	}
+	if s.Tags == nil {
+		s.Tags = make(map[string]map[string]string)
+	}
+	if s.Highlights == nil {
+		s.Highlights = make(map[int]*HighlightRecord)
+	}
+
+	return s, nil
+}
+
+// Save writes the state back to its file
+func (s *State) Save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	// Write to a temporary file first so an interrupted run never corrupts the state
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, content, 0o644); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	return nil
+}
+
+// CoverFileID returns the file ID of a cover image already uploaded to a space
+func (s *State) CoverFileID(spaceID, url string) (string, bool) {
+	fileID, ok := s.Covers[spaceID][url]
+	return fileID, ok
+}
+
+// SetCoverFileID records the file ID of a cover image uploaded to a space
+func (s *State) SetCoverFileID(spaceID, url, fileID string) {
+	if s.Covers[spaceID] == nil {
+		s.Covers[spaceID] = make(map[string]string)
+	}
+	s.Covers[spaceID][url] = fileID
+}
+
+// AuthorObjectID returns the object of an author in a space
+func (s *State) AuthorObjectID(spaceID, authorKey string) (string, bool) {
+	objectID, ok := s.Authors[spaceID][authorKey]
+	return objectID, ok
+}
+
+// SetAuthorObjectID records the object of an author in a space
+func (s *State) SetAuthorObjectID(spaceID, authorKey, objectID string) {
+	if s.Authors[spaceID] == nil {
+		s.Authors[spaceID] = make(map[string]string)
+	}
+	s.Authors[spaceID][authorKey] = objectID
+}
+
+// CollectionObjectID returns the object of a collection in a space
+func (s *State) CollectionObjectID(spaceID, name string) (string, bool) {
+	objectID, ok := s.Collections[spaceID][name]
+	return objectID, ok
+}
+
+// SetCollectionObjectID records the object of a collection in a space
+func (s *State) SetCollectionObjectID(spaceID, name, objectID string) {
+	if s.Collections[spaceID] == nil {
+		s.Collections[spaceID] = make(map[string]string)
+	}
+	s.Collections[spaceID][name] = objectID
+}
+
+// TagID returns the Anytype tag of a Readwise tag in a space
+func (s *State) TagID(spaceID, readwiseTag string) (string, bool) {
+	tagID, ok := s.Tags[spaceID][readwiseTag]
+	return tagID, ok
+}
+
+// SetTagID records the Anytype tag of a Readwise tag in a space
+func (s *State) SetTagID(spaceID, readwiseTag, tagID string) {
+	if s.Tags[spaceID] == nil {
+		s.Tags[spaceID] = make(map[string]string)
+	}
+	s.Tags[spaceID][readwiseTag] = tagID
+}
@@ -0,0 +1,139 @@
+package sync
+
+import (
+	"anytype-readwise/feature/notes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// authorSeparators splits a list of authors written as "A; B", "A & B" or "A and B"
+var authorSeparators = regexp.MustCompile(`(?i)\s*;\s*|\s+&\s+|\s+and\s+`)
+
+// nameSuffixes are the parts after a comma that belong to the previous name
+var nameSuffixes = map[string]bool{
+	"jr": true, "sr": true, "ii": true, "iii": true, "iv": true, "phd": true, "md": true,
+}
+
+// splitAuthors splits the author field into normalized "First Last" names.
+// "Last, First" is inverted when the part before the comma is a single word, otherwise commas separate authors.
+func splitAuthors(raw string) []string {
+	var authors []string
+	for _, part := range authorSeparators.Split(raw, -1) {
+		pieces := strings.Split(part, ",")
+		for i := range pieces {
+			pieces[i] = strings.Join(strings.Fields(pieces[i]), " ")
+		}
+
+		switch {
+		case len(pieces) == 2 && isNameSuffix(pieces[1]):
+			authors = append(authors, pieces[0]+" "+pieces[1])
+		case len(pieces) == 2 && !strings.Contains(pieces[0], " ") && pieces[1] != "":
+			authors = append(authors, pieces[1]+" "+pieces[0])
+		default:
+			authors = append(authors, pieces...)
+		}
+	}
+
+	// Drop empty names and duplicates
+	seen := make(map[string]bool)
+	result := authors[:0]
+	for _, author := range authors {
+		key := authorKey(author)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, author)
+	}
+	return result
+}
+
+func isNameSuffix(value string) bool {
+	return nameSuffixes[strings.ToLower(strings.Trim(value, ". "))]
+}
+
+// authorKey identifies an author regardless of case, punctuation and spacing of initials
+func authorKey(name string) string {
+	name = strings.ToLower(name)
+	name = strings.NewReplacer(".", " ", "-", " ", "'", "").Replace(name)
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// authorObjects returns the objects of the given authors, creating the missing ones
+func (s *Syncer) authorObjects(spaceID string, authors []string) ([]string, error) {
+	objectType := s.authorObjectType()
+	var objectIDs []string
+	for _, author := range authors {
+		key := authorKey(author)
+		objectID, ok := s.state.AuthorObjectID(spaceID, key)
+		if !ok {
+			index, err := s.authorIndex(spaceID, objectType)
+			if err != nil {
+				return nil, err
+			}
+			objectID = index[key]
+		}
+
+		if objectID == "" {
+			var obj *notes.AnytypeObject
+			err := withRetry("create author object", func() error {
+				var err error
+				obj, err = s.anytypeClient.CreateNamedObject(spaceID, objectType, author)
+				return err
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to create object for author %s: %w", author, err)
+			}
+			fmt.Printf("Created author object: %s (ID: %s)\n", author, obj.ID)
+			objectID = obj.ID
+		}
+
+		s.state.SetAuthorObjectID(spaceID, key, objectID)
+		objectIDs = append(objectIDs, objectID)
+	}
+	return objectIDs, nil
+}
+
+// authorIndex maps the normalized name of every existing author object to its ID, loading it once per sync
+func (s *Syncer) authorIndex(spaceID, objectType string) (map[string]string, error) {
+	key := spaceID + "/" + objectType + "/names"
+	if index, ok := s.objectIndexes[key]; ok {
+		return index, nil
+	}
+
+	var objects []notes.AnytypeGetObjectResponseItem
+	err := withRetry("get objects", func() error {
+		var err error
+		objects, err = s.anytypeClient.GetObjects(spaceID, objectType)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s objects: %w", objectType, err)
+	}
+
+	index := make(map[string]string)
+	for _, obj := range objects {
+		for _, name := range splitAuthors(obj.Name) {
+			if _, ok := index[authorKey(name)]; !ok {
+				index[authorKey(name)] = obj.ID
+			}
+		}
+	}
+	s.objectIndexes[key] = index
+	return index, nil
+}
+
+func (s *Syncer) authorObjectType() string {
+	if s.config.AuthorObjectType != "" {
+		return s.config.AuthorObjectType
+	}
+	return "Human"
+}
+
+func (s *Syncer) authorProperty() string {
+	if s.config.AuthorProperty != "" {
+		return s.config.AuthorProperty
+	}
+	return "author"
+}
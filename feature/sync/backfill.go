@@ -0,0 +1,103 @@
+package sync
+
+import (
+	"anytype-readwise/core"
+	"anytype-readwise/feature/bookmarks"
+	"anytype-readwise/feature/state"
+	"strings"
+)
+
+// adoptBackfillRecord hands the object of a book synced from the Readwise CSV export over to the
+// same book synced from the API. The export has no Readwise IDs, so the books are matched by ASIN,
+// ISBN or title and author, and their highlights by text.
+func (s *Syncer) adoptBackfillRecord(book bookmarks.ReadwiseBook, highlights []bookmarks.Highlight) *state.BookRecord {
+	if s.sourceName() != core.SourceReadwise {
+		return nil
+	}
+
+	backfillID, record := s.findBackfillRecord(book)
+	if record == nil {
+		return nil
+	}
+	delete(s.state.Books, backfillID)
+
+	// Highlight IDs were derived from the export, the ones found again take the Readwise IDs
+	byText := make(map[string]int)
+	for highlightID, highlight := range s.state.Highlights {
+		if highlight.BookID == backfillID {
+			byText[strings.TrimSpace(highlight.Text)] = highlightID
+		}
+	}
+	adopted := &state.BookRecord{
+		Source:       core.SourceReadwise,
+		Title:        record.Title,
+		SpaceID:      record.SpaceID,
+		ObjectID:     record.ObjectID,
+		CollectionID: record.CollectionID,
+	}
+	matched := make(map[int]bool)
+	for _, highlight := range highlights {
+		oldID, ok := byText[strings.TrimSpace(highlight.Text)]
+		if !ok {
+			continue
+		}
+		matched[oldID] = true
+		adopted.HighlightIDs = append(adopted.HighlightIDs, highlight.ID)
+		if objectID := record.HighlightObjects[oldID]; objectID != "" {
+			if adopted.HighlightObjects == nil {
+				adopted.HighlightObjects = make(map[int]string)
+			}
+			adopted.HighlightObjects[highlight.ID] = objectID
+		}
+		if history := s.state.Highlights[oldID]; history != nil {
+			delete(s.state.Highlights, oldID)
+			history.BookID = book.ID
+			s.state.Highlights[highlight.ID] = history
+		}
+	}
+
+	// The highlights not found again were removed since the export: they go through the removal
+	// of the next sync with their objects, and their history is dropped
+	for _, oldID := range record.HighlightIDs {
+		if matched[oldID] {
+			continue
+		}
+		adopted.HighlightIDs = append(adopted.HighlightIDs, oldID)
+		if objectID := record.HighlightObjects[oldID]; objectID != "" {
+			if adopted.HighlightObjects == nil {
+				adopted.HighlightObjects = make(map[int]string)
+			}
+			adopted.HighlightObjects[oldID] = objectID
+		}
+	}
+	for highlightID, highlight := range s.state.Highlights {
+		if highlight.BookID == backfillID {
+			delete(s.state.Highlights, highlightID)
+		}
+	}
+
+	s.report.addAction(book.Title, "adopted", "object synced from the CSV export")
+	return adopted
+}
+
+// findBackfillRecord returns the record of the CSV export sharing the most reliable key with a book
+func (s *Syncer) findBackfillRecord(book bookmarks.ReadwiseBook) (int, *state.BookRecord) {
+	for _, key := range book.MatchKeys() {
+		for bookID, record := range s.state.Books {
+			if recordSource(record) != core.SourceReadwiseCSV || record.Deleted {
+				continue
+			}
+			keys := record.MatchKeys
+			if len(keys) == 0 {
+				// Records written before the keys were kept only have the title
+				keys = bookmarks.ReadwiseBook{Title: record.Title, Author: book.Author}.MatchKeys()
+			}
+			for _, recordKey := range keys {
+				if recordKey == key {
+					return bookID, record
+				}
+			}
+		}
+	}
+	return 0, nil
+}
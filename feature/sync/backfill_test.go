@@ -0,0 +1,63 @@
+package sync
+
+import (
+	"anytype-readwise/core"
+	"anytype-readwise/feature/bookmarks"
+	"anytype-readwise/feature/state"
+	"testing"
+)
+
+func TestAdoptBackfillRecord(t *testing.T) {
+	st, err := state.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.Books[111] = &state.BookRecord{
+		Source:           core.SourceReadwiseCSV,
+		Title:            "Deep Work",
+		SpaceID:          "space",
+		ObjectID:         "csv-object",
+		HighlightIDs:     []int{1001, 1002},
+		HighlightObjects: map[int]string{1001: "csv-highlight-object", 1002: "removed-highlight-object"},
+		MatchKeys:        bookmarks.ReadwiseBook{Title: "Deep Work", Author: "Cal Newport"}.MatchKeys(),
+	}
+	st.Highlights[1001] = &state.HighlightRecord{BookID: 111, Text: "Focus is rare. "}
+	st.Highlights[1002] = &state.HighlightRecord{BookID: 111, Text: "Removed since the export"}
+	s := &Syncer{config: &core.Config{Source: core.SourceReadwise}, state: st, report: &Report{}}
+
+	book := bookmarks.ReadwiseBook{ID: 42, Title: "Deep Work: Rules for Focused Success", Author: "Cal Newport"}
+	highlights := []bookmarks.Highlight{{ID: 7, Text: "Focus is rare."}, {ID: 8, Text: "New"}}
+	record := s.adoptBackfillRecord(book, highlights)
+	if record == nil {
+		t.Fatal("the record of the CSV export was not adopted")
+	}
+	if record.ObjectID != "csv-object" || record.Source != core.SourceReadwise {
+		t.Errorf("got record %+v, want the CSV object under the readwise source", record)
+	}
+	if len(record.HighlightIDs) != 2 || record.HighlightIDs[0] != 7 || record.HighlightObjects[7] != "csv-highlight-object" {
+		t.Errorf("got highlights %v and objects %v, want the highlight 7 with the CSV object", record.HighlightIDs, record.HighlightObjects)
+	}
+	// The highlight removed since the export is left to the removal of its object
+	if removed := removedHighlights(record, highlights); len(removed) != 1 || removed[0] != 1002 || record.HighlightObjects[1002] != "removed-highlight-object" {
+		t.Errorf("got removed highlights %v and objects %v, want 1002 with its object", removed, record.HighlightObjects)
+	}
+	if _, ok := st.Highlights[1002]; ok {
+		t.Error("the history of the removed highlight is still in the state")
+	}
+	if _, ok := st.Books[111]; ok {
+		t.Error("the CSV record is still in the state")
+	}
+	if history := st.Highlights[7]; history == nil || history.BookID != 42 {
+		t.Errorf("got history %+v, want the history of the highlight 1001 moved to 7", history)
+	}
+
+	// Other sources and books that don't match are left alone
+	s.config.Source = core.SourceKindleClippings
+	if record := s.adoptBackfillRecord(book, highlights); record != nil {
+		t.Errorf("adopted %+v from a source that is not the API", record)
+	}
+	s.config.Source = core.SourceReadwise
+	if record := s.adoptBackfillRecord(bookmarks.ReadwiseBook{ID: 43, Title: "Other"}, nil); record != nil {
+		t.Errorf("adopted %+v for another book", record)
+	}
+}
@@ -0,0 +1,145 @@
+package sync
+
+import (
+	"anytype-readwise/core"
+	"anytype-readwise/feature/bookmarks"
+	"anytype-readwise/feature/notes"
+	"anytype-readwise/feature/state"
+	"errors"
+	"fmt"
+)
+
+// collectionObjectType is the Anytype type of collections
+const collectionObjectType = "Collection"
+
+// addToCollection adds the book object to its collection, or to the category sub-collection,
+// and removes it from the collection it was in when its route or category changed.
+// It returns the collection the object belongs to.
+func (s *Syncer) addToCollection(book bookmarks.ReadwiseBook, bookRoute route, objectID string, record *state.BookRecord) (string, error) {
+	// Collection objects only exist in Anytype, the import bundle names its index after the collection
+	if s.anytypeClient == nil {
+		return "", nil
+	}
+	if bookRoute.collection == "" {
+		return "", s.removeFromCollection(book, bookRoute.spaceID, objectID, record, "")
+	}
+
+	collectionID, err := s.collectionObject(bookRoute.spaceID, bookRoute.collection, "")
+	if err != nil {
+		return "", err
+	}
+	if s.config.CollectionPerCategory && book.Category != "" {
+		name := bookRoute.collection + " / " + core.TitleCase(book.Category)
+		collectionID, err = s.collectionObject(bookRoute.spaceID, name, collectionID)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// Objects only need to be added once, a recreated object is added again
+	if record != nil && record.CollectionID == collectionID && record.ObjectID == objectID {
+		return collectionID, nil
+	}
+
+	err = withRetry("add object to collection", func() error {
+		return s.anytypeClient.AddObjectsToList(bookRoute.spaceID, collectionID, []string{objectID})
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to add book %s to collection: %w", book.Title, err)
+	}
+	if err := s.removeFromCollection(book, bookRoute.spaceID, objectID, record, collectionID); err != nil {
+		return "", err
+	}
+	return collectionID, nil
+}
+
+// removeFromCollection removes the book object from the collection recorded at the last sync,
+// unless it is still the collection of the book. Objects moved to another space were archived already.
+func (s *Syncer) removeFromCollection(book bookmarks.ReadwiseBook, spaceID, objectID string, record *state.BookRecord, collectionID string) error {
+	if record == nil || record.CollectionID == "" || record.CollectionID == collectionID ||
+		record.SpaceID != spaceID || record.ObjectID != objectID {
+		return nil
+	}
+
+	err := withRetry("remove object from collection", func() error {
+		return s.anytypeClient.RemoveObjectFromList(spaceID, record.CollectionID, objectID)
+	})
+	// The collection may have been deleted in Anytype
+	if err != nil && !errors.Is(err, core.ErrNotFound) {
+		return fmt.Errorf("failed to remove book %s from its previous collection: %w", book.Title, err)
+	}
+	s.report.addAction(book.Title, "moved", "removed from its previous collection")
+	return nil
+}
+
+// collectionObject returns the collection with the given name, creating it when missing.
+// New collections with a parent are added to the parent collection.
+func (s *Syncer) collectionObject(spaceID, name, parentID string) (string, error) {
+	if objectID, ok := s.state.CollectionObjectID(spaceID, name); ok {
+		if s.collectionsChecked[objectID] {
+			return objectID, nil
+		}
+	}
+
+	index, err := s.collectionIndex(spaceID)
+	if err != nil {
+		return "", err
+	}
+	if objectID, ok := index[name]; ok {
+		s.state.SetCollectionObjectID(spaceID, name, objectID)
+		s.collectionsChecked[objectID] = true
+		return objectID, nil
+	}
+
+	var obj *notes.AnytypeObject
+	err = withRetry("create collection", func() error {
+		var err error
+		obj, err = s.anytypeClient.CreateNamedObject(spaceID, collectionObjectType, name)
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create collection %s: %w", name, err)
+	}
+	fmt.Printf("Created collection: %s (ID: %s)\n", name, obj.ID)
+
+	if parentID != "" {
+		err = withRetry("add collection to collection", func() error {
+			return s.anytypeClient.AddObjectsToList(spaceID, parentID, []string{obj.ID})
+		})
+		if errors.Is(err, core.ErrValidation) {
+			fmt.Printf("Warning: collection %s could not be added to its parent: %v\n", name, err)
+		} else if err != nil {
+			return "", fmt.Errorf("failed to add collection %s to its parent: %w", name, err)
+		}
+	}
+
+	index[name] = obj.ID
+	s.state.SetCollectionObjectID(spaceID, name, obj.ID)
+	s.collectionsChecked[obj.ID] = true
+	return obj.ID, nil
+}
+
+// collectionIndex maps the name of every collection in the space to its ID, loading it once per sync
+func (s *Syncer) collectionIndex(spaceID string) (map[string]string, error) {
+	key := spaceID + "/" + collectionObjectType + "/names"
+	if index, ok := s.objectIndexes[key]; ok {
+		return index, nil
+	}
+
+	var objects []notes.AnytypeGetObjectResponseItem
+	err := withRetry("get objects", func() error {
+		var err error
+		objects, err = s.anytypeClient.GetObjects(spaceID, collectionObjectType)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collections: %w", err)
+	}
+
+	index := make(map[string]string)
+	for _, obj := range objects {
+		index[obj.Name] = obj.ID
+	}
+	s.objectIndexes[key] = index
+	return index, nil
+}
@@ -0,0 +1,81 @@
+package sync
+
+import (
+	"anytype-readwise/core"
+	"anytype-readwise/feature/bookmarks"
+	"anytype-readwise/feature/notes"
+	"anytype-readwise/feature/state"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestAddToCollectionMovesBook(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	st, err := state.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &core.Config{CollectionPerCategory: true}
+	s := &Syncer{
+		anytypeClient:      notes.NewAnytypeClient("key", server.URL, "", config),
+		config:             config,
+		state:              st,
+		report:             &Report{},
+		collectionsChecked: map[string]bool{"library": true, "articles": true},
+	}
+	st.SetCollectionObjectID("space", "Library", "library")
+	st.SetCollectionObjectID("space", "Library / Articles", "articles")
+
+	// The book was filed under books before its category changed
+	book := bookmarks.ReadwiseBook{ID: 1, Title: "Deep Work", Category: "articles"}
+	record := &state.BookRecord{SpaceID: "space", ObjectID: "object", CollectionID: "books"}
+	collectionID, err := s.addToCollection(book, route{spaceID: "space", collection: "Library"}, "object", record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if collectionID != "articles" {
+		t.Errorf("got collection %s, want articles", collectionID)
+	}
+	want := []string{"POST /v1/spaces/space/lists/articles/objects", "DELETE /v1/spaces/space/lists/books/objects/object"}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("got requests %v, want %v", requests, want)
+	}
+
+	// A route without collection takes the book out of the one it was in
+	requests = nil
+	record.CollectionID = "articles"
+	if collectionID, err := s.addToCollection(book, route{spaceID: "space"}, "object", record); err != nil || collectionID != "" {
+		t.Fatalf("got collection %q and error %v, want none", collectionID, err)
+	}
+	if want := []string{"DELETE /v1/spaces/space/lists/articles/objects/object"}; !reflect.DeepEqual(requests, want) {
+		t.Errorf("got requests %v, want %v", requests, want)
+	}
+
+	// A book object recreated since the last sync is added to the collection it was in
+	requests = nil
+	record.CollectionID = "articles"
+	if _, err := s.addToCollection(book, route{spaceID: "space", collection: "Library"}, "new-object", record); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"POST /v1/spaces/space/lists/articles/objects"}; !reflect.DeepEqual(requests, want) {
+		t.Errorf("got requests %v, want %v", requests, want)
+	}
+
+	// An object already in its collection is left there
+	requests = nil
+	record.ObjectID = "new-object"
+	if _, err := s.addToCollection(book, route{spaceID: "space", collection: "Library"}, "new-object", record); err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 0 {
+		t.Errorf("got requests %v, want none", requests)
+	}
+}
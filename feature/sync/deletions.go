@@ -0,0 +1,131 @@
+package sync
+
+import (
+	"anytype-readwise/core"
+	"anytype-readwise/feature/bookmarks"
+	"anytype-readwise/feature/state"
+	"errors"
+	"fmt"
+)
+
+// handleDeletedBooks applies the configured deletion mode to the synced books that are no longer returned by the provider
+func (s *Syncer) handleDeletedBooks(books []bookmarks.ReadwiseBook) error {
+	// A source returning nothing, like an export file emptied or replaced by mistake, would delete everything
+	if len(books) == 0 {
+		for _, record := range s.state.Books {
+			if !record.Deleted && recordSource(record) == s.sourceName() {
+				fmt.Printf("Warning: the source returned no books, deletions are not processed\n")
+				return nil
+			}
+		}
+	}
+
+	current := make(map[int]bool, len(books))
+	for _, book := range books {
+		current[book.ID] = true
+	}
+
+	for bookID, record := range s.state.Books {
+		if current[bookID] || record.Deleted || recordSource(record) != s.sourceName() {
+			continue
+		}
+
+		fmt.Printf("Book %s was deleted from the source\n", record.Title)
+		switch s.config.OnDelete {
+		case core.OnDeleteArchive:
+			err := withRetry("archive object", func() error {
+				return s.sink.ArchiveObject(record.SpaceID, record.ObjectID)
+			})
+			if errors.Is(err, core.ErrNotFound) {
+				fmt.Printf("Warning: object of deleted book %s no longer exists\n", record.Title)
+			} else if err != nil {
+				return fmt.Errorf("failed to archive object for deleted book %s: %w", record.Title, err)
+			}
+			s.report.addAction(record.Title, "archived", "source deleted")
+		case core.OnDeleteFlag:
+			err := withRetry("flag object", func() error {
+				return s.sink.FlagObject(record.SpaceID, record.ObjectID, s.config.DeletedProperty)
+			})
+			if errors.Is(err, core.ErrNotFound) {
+				fmt.Printf("Warning: object of deleted book %s no longer exists\n", record.Title)
+			} else if err != nil {
+				return fmt.Errorf("failed to flag object for deleted book %s: %w", record.Title, err)
+			}
+			s.report.addAction(record.Title, "flagged", "source deleted")
+		default:
+			s.report.addAction(record.Title, "kept", "source deleted")
+		}
+		record.Deleted = true
+	}
+
+	return nil
+}
+
+// archiveMovedObject archives the object of a book that a routing rule moved to another space,
+// objects can't be moved between spaces so the book was created again in the new one
+func (s *Syncer) archiveMovedObject(book bookmarks.ReadwiseBook, record *state.BookRecord) {
+	err := withRetry("archive moved object", func() error {
+		return s.sink.ArchiveObject(record.SpaceID, record.ObjectID)
+	})
+	if err != nil && !errors.Is(err, core.ErrNotFound) {
+		fmt.Printf("Warning: failed to archive the previous object of %s: %v\n", book.Title, err)
+		return
+	}
+	s.report.addAction(book.Title, "moved", fmt.Sprintf("from space %s", record.SpaceID))
+}
+
+// removedHighlights returns the IDs of the highlights synced before that are no longer part of the book
+func removedHighlights(record *state.BookRecord, highlights []bookmarks.Highlight) []int {
+	if record == nil {
+		return nil
+	}
+
+	current := make(map[int]bool, len(highlights))
+	for _, highlight := range highlights {
+		current[highlight.ID] = true
+	}
+
+	var removed []int
+	for _, id := range record.HighlightIDs {
+		if !current[id] {
+			removed = append(removed, id)
+		}
+	}
+	return removed
+}
+
+// withoutDeletedHighlights drops the highlights flagged as deleted by the provider.
+// It returns a new slice, providers return the highlights they cache.
+func withoutDeletedHighlights(highlights []bookmarks.Highlight) []bookmarks.Highlight {
+	kept := make([]bookmarks.Highlight, 0, len(highlights))
+	for _, highlight := range highlights {
+		if !highlight.IsDeleted {
+			kept = append(kept, highlight)
+		}
+	}
+	return kept
+}
+
+func highlightIDs(highlights []bookmarks.Highlight) []int {
+	ids := make([]int, 0, len(highlights))
+	for _, highlight := range highlights {
+		ids = append(ids, highlight.ID)
+	}
+	return ids
+}
+
+// sourceName identifies the configured provider in the state
+func (s *Syncer) sourceName() string {
+	if s.config.Source == "" {
+		return core.SourceReadwise
+	}
+	return s.config.Source
+}
+
+// recordSource returns the provider of a state record, records written before sources existed came from Readwise
+func recordSource(record *state.BookRecord) string {
+	if record.Source == "" {
+		return core.SourceReadwise
+	}
+	return record.Source
+}
@@ -0,0 +1,61 @@
+package sync
+
+import (
+	"anytype-readwise/core"
+	"anytype-readwise/feature/bookmarks"
+	"anytype-readwise/feature/notes"
+	"anytype-readwise/feature/state"
+	"testing"
+)
+
+func TestWithoutDeletedHighlightsKeepsInput(t *testing.T) {
+	highlights := []bookmarks.Highlight{{ID: 1, IsDeleted: true}, {ID: 2}, {ID: 3}}
+
+	kept := withoutDeletedHighlights(highlights)
+	if len(kept) != 2 || kept[0].ID != 2 || kept[1].ID != 3 {
+		t.Fatalf("got %+v, want the highlights 2 and 3", kept)
+	}
+	// Providers cache the slices they return, they must not be changed
+	if highlights[0].ID != 1 || highlights[1].ID != 2 || highlights[2].ID != 3 {
+		t.Errorf("the input was modified: %+v", highlights)
+	}
+}
+
+// archivingSink records the objects archived through it
+type archivingSink struct {
+	notes.Sink
+	archived []string
+}
+
+func (s *archivingSink) ArchiveObject(spaceID string, objectID string) error {
+	s.archived = append(s.archived, objectID)
+	return nil
+}
+
+func TestHandleDeletedBooksNeedsBooks(t *testing.T) {
+	st, err := state.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.Books[1] = &state.BookRecord{Source: core.SourceKindleClippings, Title: "Deep Work", ObjectID: "deep-work"}
+	st.Books[2] = &state.BookRecord{Source: core.SourceKindleClippings, Title: "Walden", ObjectID: "walden"}
+	st.Books[3] = &state.BookRecord{Source: core.SourceKobo, Title: "Le Petit Prince", ObjectID: "petit-prince"}
+	sink := &archivingSink{}
+	s := &Syncer{config: &core.Config{Source: core.SourceKindleClippings, OnDelete: core.OnDeleteArchive}, state: st, sink: sink, report: &Report{}}
+
+	// An empty source deletes nothing
+	if err := s.handleDeletedBooks(nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(sink.archived) != 0 || st.Books[1].Deleted || st.Books[2].Deleted {
+		t.Errorf("archived %v from an empty source, want nothing", sink.archived)
+	}
+
+	// The books of the source that are no longer returned are, not the ones of other sources
+	if err := s.handleDeletedBooks([]bookmarks.ReadwiseBook{{ID: 1, Title: "Deep Work"}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(sink.archived) != 1 || sink.archived[0] != "walden" || !st.Books[2].Deleted || st.Books[3].Deleted {
+		t.Errorf("archived %v, want walden only", sink.archived)
+	}
+}
@@ -0,0 +1,138 @@
+package sync
+
+import (
+	"anytype-readwise/core"
+	"anytype-readwise/feature/bookmarks"
+	"anytype-readwise/feature/state"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// trackHighlightEdits remembers the text and note of the highlights of a book, keeping the previous
+// version when one was edited since the last sync. It returns the history of the edited highlights.
+func (s *Syncer) trackHighlightEdits(book bookmarks.ReadwiseBook, highlights []bookmarks.Highlight) map[int]*state.HighlightRecord {
+	now := time.Now().UTC()
+	current := make(map[int]bool, len(highlights))
+	for _, highlight := range highlights {
+		current[highlight.ID] = true
+	}
+	renamed := s.renamedHighlights(book, highlights, current)
+
+	history := make(map[int]*state.HighlightRecord)
+	edited := 0
+	for _, highlight := range highlights {
+		record := s.state.Highlights[highlight.ID]
+		if oldID, ok := renamed[highlight.ID]; ok {
+			record = s.state.Highlights[oldID]
+			delete(s.state.Highlights, oldID)
+			s.state.Highlights[highlight.ID] = record
+		}
+		if record == nil {
+			s.state.Highlights[highlight.ID] = &state.HighlightRecord{
+				BookID:      book.ID,
+				Text:        highlight.Text,
+				Note:        highlight.Note,
+				Updated:     highlight.Updated,
+				Location:    highlight.Location,
+				EndLocation: highlight.EndLocation,
+			}
+			continue
+		}
+
+		if record.Text != highlight.Text || record.Note != highlight.Note {
+			record.Versions = append(record.Versions, state.HighlightVersion{Text: record.Text, Note: record.Note, Updated: record.Updated})
+			// Local sources don't always date their edits
+			record.EditedAt = highlight.Updated
+			if !highlight.Updated.After(record.Updated) {
+				record.EditedAt = now
+			}
+			record.Text = highlight.Text
+			record.Note = highlight.Note
+			edited++
+		}
+		record.BookID = book.ID
+		record.Updated = highlight.Updated
+		record.Location = highlight.Location
+		record.EndLocation = highlight.EndLocation
+		if len(record.Versions) > 0 {
+			history[highlight.ID] = record
+		}
+	}
+
+	// Highlights removed from the book have no history left to render
+	for highlightID, record := range s.state.Highlights {
+		if record.BookID == book.ID && !current[highlightID] {
+			delete(s.state.Highlights, highlightID)
+		}
+	}
+
+	if edited > 0 {
+		s.report.addAction(book.Title, "edited highlights", fmt.Sprintf("%d edited in source", edited))
+	}
+	return history
+}
+
+// highlightPosition is where a highlight starts and ends in its book
+type highlightPosition struct {
+	start, end int
+}
+
+// renamedHighlights maps the new IDs of the highlights edited in a source of core.TextDerivedIDSources
+// to their previous ID. Their ID changed with their text, so a new highlight is matched to the removed
+// one at the same position, only when no other highlight, new or removed, has that position.
+func (s *Syncer) renamedHighlights(book bookmarks.ReadwiseBook, highlights []bookmarks.Highlight, current map[int]bool) map[int]int {
+	removed := make(map[highlightPosition][]int)
+	for highlightID, record := range s.state.Highlights {
+		if record.BookID == book.ID && !current[highlightID] && record.Location != 0 {
+			position := highlightPosition{record.Location, record.EndLocation}
+			removed[position] = append(removed[position], highlightID)
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+
+	added := make(map[highlightPosition][]int)
+	for _, highlight := range highlights {
+		if s.state.Highlights[highlight.ID] == nil && s.textDerivedIDs(highlight) {
+			position := highlightPosition{highlight.Location, highlight.EndLocation}
+			added[position] = append(added[position], highlight.ID)
+		}
+	}
+
+	renamed := make(map[int]int)
+	for position, newIDs := range added {
+		if oldIDs := removed[position]; len(newIDs) == 1 && len(oldIDs) == 1 {
+			renamed[newIDs[0]] = oldIDs[0]
+		}
+	}
+	return renamed
+}
+
+// textDerivedIDs tells whether the source a highlight was read from derives its ID from the text
+func (s *Syncer) textDerivedIDs(highlight bookmarks.Highlight) bool {
+	// Readwise books name the app they were read in, so only the provider tells where the IDs come from
+	source := s.sourceName()
+	if source == core.SourceComposite {
+		source = highlight.Origin
+	}
+	return core.ContainsFold(core.TextDerivedIDSources, source)
+}
+
+// editedHighlights lists the highlights edited since the given date, the latest edits first
+func (s *Syncer) editedHighlights(since time.Time) []ReportEdit {
+	var edits []ReportEdit
+	for _, record := range s.state.Highlights {
+		if record.EditedAt.IsZero() || record.EditedAt.Before(since) {
+			continue
+		}
+		edit := ReportEdit{Text: record.Text, EditedAt: record.EditedAt, Versions: len(record.Versions)}
+		if book, ok := s.state.Books[record.BookID]; ok {
+			edit.Book = book.Title
+		}
+		edits = append(edits, edit)
+	}
+	sort.Slice(edits, func(i, j int) bool { return edits[i].EditedAt.After(edits[j].EditedAt) })
+	return edits
+}
@@ -0,0 +1,88 @@
+package sync
+
+import (
+	"anytype-readwise/core"
+	"anytype-readwise/feature/bookmarks"
+	"anytype-readwise/feature/state"
+	"testing"
+	"time"
+)
+
+func TestTrackHighlightEdits(t *testing.T) {
+	st, err := state.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Syncer{config: &core.Config{Source: core.SourceKindleClippings}, state: st, report: &Report{}}
+	book := bookmarks.ReadwiseBook{ID: 1, Title: "Deep Work"}
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	s.trackHighlightEdits(book, []bookmarks.Highlight{
+		{ID: 10, Text: "Focus is rare", Updated: day},
+		{ID: 11, Text: "Deep work", Location: 120, Updated: day},
+	})
+
+	// The note of 10 is edited in place, the text of the highlight at 120 changes its ID
+	history := s.trackHighlightEdits(book, []bookmarks.Highlight{
+		{ID: 10, Text: "Focus is rare", Note: "Added", Updated: day.AddDate(0, 0, 1)},
+		{ID: 12, Text: "Deep work is valuable", Location: 120, Updated: day.AddDate(0, 0, 2)},
+	})
+	if record := history[10]; record == nil || len(record.Versions) != 1 || record.Versions[0].Note != "" {
+		t.Errorf("got history %+v for 10, want the version without the note", record)
+	}
+	if record := history[12]; record == nil || len(record.Versions) != 1 || record.Versions[0].Text != "Deep work" {
+		t.Errorf("got history %+v for 12, want the previous text of the highlight at 120", record)
+	}
+	if _, ok := st.Highlights[11]; ok {
+		t.Error("the record of the previous ID is still in the state")
+	}
+}
+
+func TestTrackHighlightEditsKeepsReadwiseIDs(t *testing.T) {
+	st, err := state.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Syncer{config: &core.Config{Source: core.SourceReadwise}, state: st, report: &Report{}}
+	book := bookmarks.ReadwiseBook{ID: 1, Title: "Deep Work", Source: "kindle"}
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	s.trackHighlightEdits(book, []bookmarks.Highlight{{ID: 11, Text: "Deep work", Location: 120, Updated: day}})
+
+	// Readwise IDs are stable, so a highlight deleted and another added at the same location are unrelated
+	history := s.trackHighlightEdits(book, []bookmarks.Highlight{{ID: 12, Text: "Shallow work", Location: 120, Updated: day.AddDate(0, 0, 1)}})
+	if len(history) != 0 {
+		t.Errorf("got history %+v, want none", history)
+	}
+	if record := st.Highlights[12]; record == nil || len(record.Versions) != 0 || record.Text != "Shallow work" {
+		t.Errorf("got record %+v for 12, want a new record", record)
+	}
+	if _, ok := st.Highlights[11]; ok {
+		t.Error("the record of the deleted highlight is still in the state")
+	}
+}
+
+func TestTrackHighlightEditsNeedsUniquePosition(t *testing.T) {
+	st, err := state.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Syncer{config: &core.Config{Source: core.SourceKindleClippings}, state: st, report: &Report{}}
+	book := bookmarks.ReadwiseBook{ID: 1, Title: "Deep Work"}
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	s.trackHighlightEdits(book, []bookmarks.Highlight{
+		{ID: 11, Text: "Deep work", Location: 120, EndLocation: 122, Updated: day},
+		{ID: 13, Text: "Focus", Location: 200, Updated: day},
+	})
+
+	// Two new highlights at 200 can't tell which one replaced 13, and 120-125 is not where 11 was
+	history := s.trackHighlightEdits(book, []bookmarks.Highlight{
+		{ID: 12, Text: "Deep work is valuable", Location: 120, EndLocation: 125, Updated: day},
+		{ID: 14, Text: "Focus is rare", Location: 200, Updated: day},
+		{ID: 15, Text: "Focus matters", Location: 200, Updated: day},
+	})
+	if len(history) != 0 {
+		t.Errorf("got history %+v, want none", history)
+	}
+}
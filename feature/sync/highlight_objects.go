@@ -0,0 +1,183 @@
+package sync
+
+import (
+	"anytype-readwise/core"
+	"anytype-readwise/feature/bookmarks"
+	"anytype-readwise/feature/notes"
+	"anytype-readwise/feature/state"
+	"anytype-readwise/feature/templates"
+	"errors"
+	"fmt"
+)
+
+// syncHighlightObjects creates or updates one object per highlight, linked to the book object.
+// sourceIDs are the highlights returned by the source, before the processing stages and filter.
+// It returns the object ID of every highlight.
+func (s *Syncer) syncHighlightObjects(book bookmarks.ReadwiseBook, spaceID string, bookObjectID string, highlights []bookmarks.Highlight, sourceIDs []int, record *state.BookRecord) (map[int]string, error) {
+	opts := notes.HighlightObjectOptions{
+		ObjectType:   s.highlightObjectType(),
+		BookObjectID: bookObjectID,
+		Properties:   s.config.HighlightProperties.WithDefaults(),
+	}
+
+	objectIDs := make(map[int]string, len(highlights))
+	for _, highlight := range highlights {
+		objectID, err := s.findHighlightObject(spaceID, highlight.ID, record)
+		if err != nil {
+			return nil, err
+		}
+
+		opts := opts
+		if s.config.SyncTags {
+			opts.TagProperty = s.tagProperty()
+			opts.TagIDs, err = s.tagIDs(spaceID, highlight.Tags)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		var obj *notes.AnytypeObject
+		err = withRetry("create or update highlight object", func() error {
+			var err error
+			obj, err = s.anytypeClient.CreateOrUpdateHighlightObject(spaceID, objectID, highlight, opts)
+			return err
+		})
+		if errors.Is(err, core.ErrNotFound) && objectID != "" {
+			// The object was deleted in Anytype, create it again
+			err = withRetry("create highlight object", func() error {
+				var err error
+				obj, err = s.anytypeClient.CreateOrUpdateHighlightObject(spaceID, "", highlight, opts)
+				return err
+			})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to sync highlight %d: %w", highlight.ID, err)
+		}
+
+		if obj != nil && obj.ID != "" {
+			objectID = obj.ID
+		}
+		objectIDs[highlight.ID] = objectID
+	}
+
+	if record != nil {
+		inSource := make(map[int]bool, len(sourceIDs))
+		for _, id := range sourceIDs {
+			inSource[id] = true
+		}
+		s.handleRemovedHighlightObjects(book, record, inSource)
+
+		// Highlights dropped by the filter or merged into another one are still in the source,
+		// their objects are kept for when they are rendered again
+		for highlightID, objectID := range record.HighlightObjects {
+			if inSource[highlightID] && objectIDs[highlightID] == "" {
+				objectIDs[highlightID] = objectID
+			}
+		}
+	}
+
+	return objectIDs, nil
+}
+
+// findHighlightObject returns the object of a highlight from the state, or from the objects in the space
+func (s *Syncer) findHighlightObject(spaceID string, highlightID int, record *state.BookRecord) (string, error) {
+	if record != nil && record.HighlightObjects[highlightID] != "" {
+		return record.HighlightObjects[highlightID], nil
+	}
+
+	index, err := s.objectIndex(spaceID, s.highlightObjectType())
+	if err != nil {
+		return "", err
+	}
+	return index[notes.HighlightDescription(highlightID)], nil
+}
+
+// objectIndex maps the description of every object of a type to its ID, loading it once per sync
+func (s *Syncer) objectIndex(spaceID, objectType string) (map[string]string, error) {
+	key := spaceID + "/" + objectType
+	if index, ok := s.objectIndexes[key]; ok {
+		return index, nil
+	}
+
+	var objects []notes.AnytypeGetObjectResponseItem
+	err := withRetry("get objects", func() error {
+		var err error
+		objects, err = s.anytypeClient.GetObjects(spaceID, objectType)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s objects: %w", objectType, err)
+	}
+
+	index := make(map[string]string)
+	for _, obj := range objects {
+		for _, prop := range obj.Properties {
+			if prop.Key == "description" && prop.Value != "" {
+				index[prop.Value] = obj.ID
+			}
+		}
+	}
+	s.objectIndexes[key] = index
+	return index, nil
+}
+
+// handleRemovedHighlightObjects applies the deletion mode to the objects of highlights removed from the source
+func (s *Syncer) handleRemovedHighlightObjects(book bookmarks.ReadwiseBook, record *state.BookRecord, inSource map[int]bool) {
+	for highlightID, objectID := range record.HighlightObjects {
+		if inSource[highlightID] {
+			continue
+		}
+
+		var err error
+		switch s.config.OnDelete {
+		case core.OnDeleteArchive:
+			err = withRetry("archive highlight object", func() error {
+				return s.anytypeClient.ArchiveObject(record.SpaceID, objectID)
+			})
+			if err == nil {
+				s.report.addAction(book.Title, "archived highlight", fmt.Sprintf("highlight %d", highlightID))
+			}
+		case core.OnDeleteFlag:
+			err = withRetry("flag highlight object", func() error {
+				return s.anytypeClient.FlagObject(record.SpaceID, objectID, s.config.DeletedProperty)
+			})
+			if err == nil {
+				s.report.addAction(book.Title, "flagged highlight", fmt.Sprintf("highlight %d", highlightID))
+			}
+		}
+		if err != nil && !errors.Is(err, core.ErrNotFound) {
+			fmt.Printf("Warning: failed to handle removed highlight %d: %v\n", highlightID, err)
+		}
+	}
+}
+
+func (s *Syncer) highlightObjectType() string {
+	if s.config.HighlightObjectType != "" {
+		return s.config.HighlightObjectType
+	}
+	return "Highlight"
+}
+
+// linkHighlightObjects updates the book object so its body and relation point to its highlight objects
+func (s *Syncer) linkHighlightObjects(bookRoute route, bookObjectID string, templateData templates.TemplateData, objectIDs map[int]string) error {
+	content, err := bookRoute.templateProvider.Render(templateData)
+	if err != nil {
+		return fmt.Errorf("failed to render template for book %s: %w", templateData.Book.Title, err)
+	}
+
+	req := notes.AnytypeUpdateObjectRequest{Body: content}
+	if key := s.config.HighlightProperties.BookHighlights; key != "" && len(objectIDs) > 0 {
+		ids := make([]string, 0, len(objectIDs))
+		for _, highlight := range templateData.Highlights {
+			if objectID := objectIDs[highlight.ID]; objectID != "" {
+				ids = append(ids, objectID)
+			}
+		}
+		req.Properties = []notes.CreateObjectProperty{{Key: key, Objects: ids}}
+	}
+
+	return withRetry("link highlight objects", func() error {
+		_, err := s.anytypeClient.UpdateObject(bookRoute.spaceID, bookObjectID, req)
+		return err
+	})
+}
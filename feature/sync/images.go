@@ -0,0 +1,37 @@
+package sync
+
+import (
+	"anytype-readwise/core"
+	"anytype-readwise/feature/bookmarks"
+	"fmt"
+)
+
+// applyCoverImage uploads the book cover image once per space and sets it as icon and/or cover
+func (s *Syncer) applyCoverImage(book bookmarks.ReadwiseBook, bookRoute *route) {
+	mode := s.config.CoverImage
+	if mode == "" || book.CoverImageURL == "" {
+		return
+	}
+
+	fileID, ok := s.state.CoverFileID(bookRoute.spaceID, book.CoverImageURL)
+	if !ok {
+		err := withRetry("upload cover image", func() error {
+			var err error
+			fileID, err = s.anytypeClient.UploadImageFromURL(bookRoute.spaceID, book.CoverImageURL)
+			return err
+		})
+		if err != nil {
+			fmt.Printf("Warning: failed to upload cover image for book %s: %v\n", book.Title, err)
+			return
+		}
+		s.state.SetCoverFileID(bookRoute.spaceID, book.CoverImageURL, fileID)
+	}
+
+	if mode == core.CoverImageIcon || mode == core.CoverImageBoth {
+		bookRoute.objectOptions.IconFileID = fileID
+	}
+	if mode == core.CoverImageCover || mode == core.CoverImageBoth {
+		bookRoute.objectOptions.CoverFileID = fileID
+		bookRoute.objectOptions.CoverProperty = s.config.CoverProperty
+	}
+}
@@ -0,0 +1,61 @@
+package sync
+
+import (
+	"anytype-readwise/core"
+	"anytype-readwise/feature/notes"
+	"anytype-readwise/feature/templates"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// mappedProperties renders the configured property mappings for a book
+func (s *Syncer) mappedProperties(data templates.TemplateData) ([]notes.CreateObjectProperty, error) {
+	var props []notes.CreateObjectProperty
+	for _, renderer := range s.propertyRenderers {
+		value, err := renderer.Render(data)
+		if err != nil {
+			return nil, err
+		}
+		if value == "" {
+			continue
+		}
+
+		prop, err := propertyValue(renderer.Key, renderer.Format, value)
+		if err != nil {
+			return nil, fmt.Errorf("property %s: %w", renderer.Key, err)
+		}
+		props = append(props, prop)
+	}
+	return props, nil
+}
+
+// propertyValue converts a rendered value to a property of the given format
+func propertyValue(key, format, value string) (notes.CreateObjectProperty, error) {
+	prop := notes.CreateObjectProperty{Key: key}
+	switch format {
+	case core.PropertyFormatURL:
+		prop.URL = value
+	case core.PropertyFormatNumber:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return prop, fmt.Errorf("%w: invalid number %q", core.ErrValidation, value)
+		}
+		prop.Number = &number
+	case core.PropertyFormatDate:
+		date, err := core.ParseDate(value)
+		if err != nil {
+			return prop, fmt.Errorf("%w: %v", core.ErrValidation, err)
+		}
+		prop.Date = date.Format(time.RFC3339)
+	case core.PropertyFormatCheckbox:
+		checked, err := strconv.ParseBool(value)
+		if err != nil {
+			return prop, fmt.Errorf("%w: invalid checkbox value %q", core.ErrValidation, value)
+		}
+		prop.Checkbox = &checked
+	default:
+		prop.Value = value
+	}
+	return prop, nil
+}
@@ -0,0 +1,74 @@
+package sync
+
+import (
+	"anytype-readwise/core"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Report summarizes what a sync run did
+type Report struct {
+	Synced  int
+	Skipped int
+	Failed  int
+	// HighlightsDropped is the number of highlights removed by the highlight filter
+	HighlightsDropped int
+	Actions           []ReportAction
+	// EditedSince is the date the edited highlights are listed from, zero to not list them
+	EditedSince time.Time
+	Edits       []ReportEdit
+}
+
+// ReportAction is a notable change made to a single book
+type ReportAction struct {
+	Book   string
+	Action string
+	Detail string
+}
+
+// ReportEdit is a highlight edited since the date of the report
+type ReportEdit struct {
+	Book     string
+	Text     string
+	EditedAt time.Time
+	// Versions is the number of previous versions kept
+	Versions int
+}
+
+func (r *Report) addAction(book, action, detail string) {
+	r.Actions = append(r.Actions, ReportAction{Book: book, Action: action, Detail: detail})
+}
+
+// Print writes the report to stdout
+func (r *Report) Print() {
+	fmt.Println("Sync report:")
+	fmt.Printf("  Synced: %d\n", r.Synced)
+	fmt.Printf("  Skipped: %d\n", r.Skipped)
+	fmt.Printf("  Failed: %d\n", r.Failed)
+	fmt.Printf("  Highlights dropped by filters: %d\n", r.HighlightsDropped)
+
+	if len(r.Actions) > 0 {
+		fmt.Println("  Actions:")
+		for _, action := range r.Actions {
+			if action.Detail != "" {
+				fmt.Printf("    - %s: %s (%s)\n", action.Action, action.Book, action.Detail)
+			} else {
+				fmt.Printf("    - %s: %s\n", action.Action, action.Book)
+			}
+		}
+	}
+
+	if r.EditedSince.IsZero() {
+		return
+	}
+	fmt.Printf("  Highlights edited since %s: %d\n", r.EditedSince.Format("2006-01-02"), len(r.Edits))
+	for _, edit := range r.Edits {
+		fmt.Printf("    - %s: %s: %q (%d previous versions)\n", edit.EditedAt.Local().Format("2006-01-02 15:04"), edit.Book, excerpt(edit.Text, 80), edit.Versions)
+	}
+}
+
+// excerpt returns the first characters of a text on a single line
+func excerpt(text string, n int) string {
+	return core.Truncate(strings.Join(strings.Fields(text), " "), n)
+}
@@ -0,0 +1,49 @@
+package sync
+
+import (
+	"anytype-readwise/core"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	maxAttempts       = 4
+	initialRetryDelay = 2 * time.Second
+	maxRetryDelay     = time.Minute
+)
+
+// withRetry runs fn again when it fails with a rate limit or an unavailable server,
+// waiting for the delay requested by the API or backing off exponentially
+func withRetry(operation string, fn func() error) error {
+	delay := initialRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt == maxAttempts || !isRetryable(err) {
+			return err
+		}
+
+		wait := delay
+		var apiErr *core.APIError
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+			wait = apiErr.RetryAfter
+		}
+		if wait > maxRetryDelay {
+			wait = maxRetryDelay
+		}
+
+		fmt.Printf("Warning: %s failed (%v), retrying in %s (attempt %d/%d)\n", operation, err, wait, attempt+1, maxAttempts)
+		time.Sleep(wait)
+		delay *= 2
+	}
+}
+
+// isRetryable reports whether an operation may succeed if attempted again
+func isRetryable(err error) bool {
+	return errors.Is(err, core.ErrRateLimited) || errors.Is(err, core.ErrServerUnavailable)
+}
+
+// isSkippable reports whether the sync can go on with the next book after this error
+func isSkippable(err error) bool {
+	return errors.Is(err, core.ErrNotFound) || errors.Is(err, core.ErrValidation)
+}
@@ -0,0 +1,86 @@
+package sync
+
+import (
+	"anytype-readwise/core"
+	"anytype-readwise/feature/bookmarks"
+	"regexp"
+	"strings"
+)
+
+// Router picks the routing rule that applies to a book
+type Router struct {
+	rules []compiledRule
+}
+
+type compiledRule struct {
+	rule       core.RoutingRule
+	titleRegex *regexp.Regexp
+}
+
+// NewRouter compiles the routing rules. Rules are evaluated in order and the first match wins.
+func NewRouter(rules []core.RoutingRule) (*Router, error) {
+	router := &Router{}
+	for _, rule := range rules {
+		compiled := compiledRule{rule: rule}
+		if rule.Match.TitleRegex != "" {
+			re, err := regexp.Compile(rule.Match.TitleRegex)
+			if err != nil {
+				return nil, err
+			}
+			compiled.titleRegex = re
+		}
+		router.rules = append(router.rules, compiled)
+	}
+	return router, nil
+}
+
+// Match returns the first rule matching the book, or nil if none does
+func (r *Router) Match(book bookmarks.ReadwiseBook) *core.RoutingRule {
+	for i := range r.rules {
+		if r.rules[i].matches(book) {
+			return &r.rules[i].rule
+		}
+	}
+	return nil
+}
+
+func (c compiledRule) matches(book bookmarks.ReadwiseBook) bool {
+	match := c.rule.Match
+
+	if len(match.Categories) > 0 && !core.ContainsFold(match.Categories, book.Category) {
+		return false
+	}
+	if len(match.Sources) > 0 && !core.ContainsFold(match.Sources, book.Source) {
+		return false
+	}
+	if len(match.Tags) > 0 {
+		found := false
+		for _, tag := range book.AllTags() {
+			if core.ContainsFold(match.Tags, tag.Name) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(match.Authors) > 0 {
+		found := false
+		author := strings.ToLower(book.Author)
+		for _, candidate := range match.Authors {
+			if strings.Contains(author, strings.ToLower(candidate)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if c.titleRegex != nil && !c.titleRegex.MatchString(book.Title) {
+		return false
+	}
+
+	return true
+}
@@ -3,37 +3,148 @@ package sync
 import (
 	"anytype-readwise/core"
 	"anytype-readwise/feature/bookmarks"
+	"anytype-readwise/feature/highlights"
 	"anytype-readwise/feature/notes"
+	"anytype-readwise/feature/state"
 	"anytype-readwise/feature/templates"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 )
 
 type Syncer struct {
 	bookmarksProvider bookmarks.BookmarksProvider
+	sink              notes.Sink
+	// anytypeClient is set when writing to Anytype, for the features only Anytype has
 	anytypeClient     *notes.AnytypeClient
 	templateProvider  templates.TemplateProvider
 	config            *core.Config
+	router            *Router
+	ruleTemplates     map[string]templates.TemplateProvider
+	nameRenderer      *templates.NameRenderer
+	ruleNames         map[string]*templates.NameRenderer
+	propertyRenderers []*templates.PropertyRenderer
+	highlightPipeline highlights.Pipeline
+	highlightFilter   *highlights.Filter
+	state             *state.State
+	report            *Report
+	objectIndexes     map[string]map[string]string
+	// collectionsChecked holds the collections known to exist in Anytype during this sync
+	collectionsChecked map[string]bool
+	tagCache           map[string]*spaceTags
+	// editedSince is the date the report lists the edited highlights from
+	editedSince time.Time
 }
 
-func NewSyncer(bookmarksProvider bookmarks.BookmarksProvider, anytypeClient *notes.AnytypeClient, templateProvider templates.TemplateProvider, config *core.Config) *Syncer {
+// route is the resolved destination of a single book
+type route struct {
+	spaceID          string
+	objectOptions    notes.BookObjectOptions
+	templateProvider templates.TemplateProvider
+	nameRenderer     *templates.NameRenderer
+	collection       string
+	skip             bool
+}
+
+func NewSyncer(bookmarksProvider bookmarks.BookmarksProvider, sink notes.Sink, templateProvider templates.TemplateProvider, config *core.Config) (*Syncer, error) {
+	router, err := NewRouter(config.Rules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile routing rules: %w", err)
+	}
+
+	nameRenderer, err := templates.NewNameRenderer(config.NamePattern)
+	if err != nil {
+		return nil, err
+	}
+
+	ruleNames := make(map[string]*templates.NameRenderer)
+	for _, rule := range config.Rules {
+		if rule.NamePattern == "" {
+			continue
+		}
+		renderer, err := templates.NewNameRenderer(rule.NamePattern)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", rule.Name, err)
+		}
+		ruleNames[rule.NamePattern] = renderer
+	}
+
+	var propertyRenderers []*templates.PropertyRenderer
+	for _, mapping := range config.Properties {
+		renderer, err := templates.NewPropertyRenderer(mapping.Key, mapping.Format, mapping.Value)
+		if err != nil {
+			return nil, err
+		}
+		propertyRenderers = append(propertyRenderers, renderer)
+	}
+
+	var pipeline highlights.Pipeline
+	if config.NoteConventions {
+		pipeline = append(pipeline, highlights.NewNoteConventions())
+	}
+
+	highlightFilter, err := highlights.NewFilter(config.HighlightFilter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile highlight filter: %w", err)
+	}
+
+	var editedSince time.Time
+	if config.EditedSince != "" {
+		if editedSince, err = core.ParseDate(config.EditedSince); err != nil {
+			return nil, fmt.Errorf("invalid edited_since date: %w", err)
+		}
+	}
+
+	anytypeClient, _ := sink.(*notes.AnytypeClient)
+
 	return &Syncer{
 		bookmarksProvider: bookmarksProvider,
+		sink:              sink,
 		anytypeClient:     anytypeClient,
 		templateProvider:  templateProvider,
 		config:            config,
-	}
+		router:            router,
+		ruleTemplates:     make(map[string]templates.TemplateProvider),
+		nameRenderer:      nameRenderer,
+		ruleNames:         ruleNames,
+		propertyRenderers: propertyRenderers,
+		highlightPipeline: pipeline,
+		highlightFilter:   highlightFilter,
+		editedSince:       editedSince,
+	}, nil
 }
 
-func (s *Syncer) Sync() error {
-	fmt.Println("Starting bookmark sync to Anytype...")
+func (s *Syncer) Sync() (err error) {
+	fmt.Println("Starting bookmark sync...")
+
+	// Load the local sync state and persist it even when the sync fails halfway
+	s.state, err = state.Load(s.config.StatePath)
+	if err != nil {
+		return err
+	}
+	s.report = &Report{EditedSince: s.editedSince}
+	s.objectIndexes = make(map[string]map[string]string)
+	s.collectionsChecked = make(map[string]bool)
+	s.tagCache = make(map[string]*spaceTags)
+	defer func() {
+		if saveErr := s.state.Save(); saveErr != nil && err == nil {
+			err = saveErr
+		}
+		if !s.editedSince.IsZero() {
+			s.report.Edits = s.editedHighlights(s.editedSince)
+		}
+		s.report.Print()
+	}()
 
 	// Get space ID
 	var spaceID string
-	var err error
 	if s.config.SpaceID == "" {
 		fmt.Println("No space ID specified, using first space in list...")
-		spaceID, err = s.anytypeClient.GetSpaceID()
+		err = withRetry("get space ID", func() error {
+			spaceID, err = s.sink.GetSpaceID()
+			return err
+		})
 	} else {
 		spaceID = s.config.SpaceID
 	}
@@ -45,7 +156,11 @@ func (s *Syncer) Sync() error {
 
 	// Fetch books from the bookmarks provider
 	fmt.Println("Fetching books from bookmarks provider...")
-	books, err := s.bookmarksProvider.GetBooks()
+	var books []bookmarks.ReadwiseBook
+	err = withRetry("fetch books", func() error {
+		books, err = s.bookmarksProvider.GetBooks()
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to fetch books: %w", err)
 	}
@@ -55,35 +170,246 @@ func (s *Syncer) Sync() error {
 	for i, book := range books {
 		fmt.Printf("Processing book %d/%d: %s by %s\n", i+1, len(books), book.Title, book.Author)
 
-		// Fetch highlights for this book
-		highlights, err := s.bookmarksProvider.GetHighlights(book.ID)
-		if err != nil {
-			fmt.Printf("Warning: failed to fetch highlights for book %s: %v\n", book.Title, err)
-			highlights = []bookmarks.Highlight{} // Continue with empty highlights
+		if err := s.syncBook(book, spaceID); err != nil {
+			if !isSkippable(err) {
+				return err
+			}
+			fmt.Printf("Warning: skipping book %s: %v\n", book.Title, err)
+			s.report.Failed++
+			s.report.addAction(book.Title, "failed", err.Error())
+		}
+	}
+
+	return s.handleDeletedBooks(books)
+}
+
+// syncBook renders a single book and creates or updates its Anytype object
+func (s *Syncer) syncBook(book bookmarks.ReadwiseBook, spaceID string) error {
+	bookRoute := s.resolveRoute(book, spaceID)
+	if bookRoute.skip {
+		fmt.Println("Skipping book due to routing rules")
+		s.report.Skipped++
+		return nil
+	}
+	s.applyCoverImage(book, &bookRoute)
+
+	// Fetch highlights for this book
+	record := s.state.Books[book.ID]
+	var highlights []bookmarks.Highlight
+	err := withRetry("fetch highlights", func() error {
+		var err error
+		highlights, err = s.bookmarksProvider.GetHighlights(book.ID)
+		return err
+	})
+	highlightsFetched := err == nil
+	if err != nil {
+		if errors.Is(err, core.ErrUnauthorized) {
+			return fmt.Errorf("failed to fetch highlights for book %s: %w", book.Title, err)
+		}
+		fmt.Printf("Warning: failed to fetch highlights for book %s: %v\n", book.Title, err)
+		highlights = []bookmarks.Highlight{} // Continue with empty highlights
+	}
+	highlights = withoutDeletedHighlights(highlights)
+	if record == nil && highlightsFetched {
+		record = s.adoptBackfillRecord(book, highlights)
+	}
+	if highlightsFetched {
+		if removed := removedHighlights(record, highlights); len(removed) > 0 {
+			s.report.addAction(book.Title, "stripped highlights", fmt.Sprintf("%d removed from source", len(removed)))
 		}
+	}
+
+	fmt.Printf("Found %d highlights\n", len(highlights))
+	sourceHighlightIDs := highlightIDs(highlights)
+
+	// Compare with the last sync before the processing stages change the notes
+	var history map[int]*state.HighlightRecord
+	if highlightsFetched {
+		history = s.trackHighlightEdits(book, highlights)
+	}
 
-		fmt.Printf("Found %d highlights\n", len(highlights))
+	// Run the highlight processing stages before rendering
+	highlights = s.highlightPipeline.Process(book, highlights)
 
-		// Render the template
-		templateData := templates.TemplateData{
-			Book:       book,
-			Highlights: highlights,
-			SyncDate:   time.Now().Format("January 2, 2006"),
+	// Filter after processing, so tags from notes can be used in the conditions
+	before := len(highlights)
+	highlights = s.highlightFilter.Process(book, highlights)
+	if dropped := before - len(highlights); dropped > 0 {
+		fmt.Printf("Filtered out %d highlights\n", dropped)
+		s.report.HighlightsDropped += dropped
+		s.report.addAction(book.Title, "filtered highlights", fmt.Sprintf("%d dropped", dropped))
+	}
+
+	// Render the template
+	templateData := templates.TemplateData{
+		Book:       book,
+		Highlights: highlights,
+		SyncDate:   time.Now().Format("January 2, 2006"),
+		Authors:    splitAuthors(book.Author),
+		History:    history,
+	}
+	content, err := bookRoute.templateProvider.Render(templateData)
+	if err != nil {
+		return fmt.Errorf("failed to render template for book %s: %w", book.Title, err)
+	}
+	bookRoute.objectOptions.Name, err = bookRoute.nameRenderer.Render(templateData)
+	if err != nil {
+		return fmt.Errorf("failed to render name for book %s: %w", book.Title, err)
+	}
+
+	mapped, err := s.mappedProperties(templateData)
+	if err != nil {
+		return fmt.Errorf("failed to render properties for book %s: %w", book.Title, err)
+	}
+	bookRoute.objectOptions.Properties = append(bookRoute.objectOptions.Properties, mapped...)
+	bookRoute.objectOptions.Authors = templateData.Authors
+
+	if s.config.AuthorObjects && len(templateData.Authors) > 0 {
+		authorIDs, err := s.authorObjects(bookRoute.spaceID, templateData.Authors)
+		if err != nil {
+			return err
 		}
-		content, err := s.templateProvider.Render(templateData)
+		bookRoute.objectOptions.Properties = append(bookRoute.objectOptions.Properties,
+			notes.CreateObjectProperty{Key: s.authorProperty(), Objects: authorIDs})
+	}
+
+	if s.config.SyncTags {
+		tagIDs, err := s.tagIDs(bookRoute.spaceID, s.bookTags(book, highlights))
 		if err != nil {
-			return fmt.Errorf("failed to render template for book %s: %w", book.Title, err)
+			return err
+		}
+		bookRoute.objectOptions.Properties = append(bookRoute.objectOptions.Properties,
+			notes.MultiSelectProperty(s.tagProperty(), tagIDs))
+	}
+
+	// The object synced before is updated even when a rule now routes the book to another type
+	if record != nil && record.SpaceID == bookRoute.spaceID {
+		bookRoute.objectOptions.ObjectID = record.ObjectID
+	}
+
+	// Create or update object in Anytype
+	var obj *notes.AnytypeObject
+	err = withRetry("create or update object", func() error {
+		var err error
+		obj, err = s.sink.CreateOrUpdateNoteFromBook(bookRoute.spaceID, book, content, bookRoute.objectOptions)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create or update object for book %s: %w", book.Title, err)
+	}
+	s.report.Synced++
+	if obj != nil {
+		fmt.Printf("Created or updated object: %s (ID: %s)\n", obj.Name, obj.ID)
+
+		newRecord := &state.BookRecord{
+			Source:       s.sourceName(),
+			Title:        book.Title,
+			SpaceID:      bookRoute.spaceID,
+			ObjectID:     obj.ID,
+			HighlightIDs: sourceHighlightIDs,
+			MatchKeys:    book.MatchKeys(),
+		}
+		if !highlightsFetched && record != nil {
+			newRecord.HighlightIDs = record.HighlightIDs
+			newRecord.HighlightObjects = record.HighlightObjects
+		}
+		s.state.Books[book.ID] = newRecord
+		if record != nil && record.SpaceID != bookRoute.spaceID {
+			s.archiveMovedObject(book, record)
 		}
 
-		// Create or update object in Anytype
-		obj, err := s.anytypeClient.CreateOrUpdateNoteFromBook(spaceID, book, content)
+		newRecord.CollectionID, err = s.addToCollection(book, bookRoute, obj.ID, record)
 		if err != nil {
-			return fmt.Errorf("failed to create or update object for book %s: %w", book.Title, err)
+			return err
 		}
-		if obj == nil {
-			fmt.Printf("Created or updated object: %s (ID: %s)\n", obj.Name, obj.ID)
+
+		if s.config.HighlightObjects && highlightsFetched {
+			objectIDs, err := s.syncHighlightObjects(book, bookRoute.spaceID, obj.ID, highlights, sourceHighlightIDs, record)
+			if err != nil {
+				return err
+			}
+			newRecord.HighlightObjects = objectIDs
+
+			// Render the book again, now linking to its highlight objects
+			templateData.HighlightObjects = make(map[int]string, len(objectIDs))
+			for highlightID, objectID := range objectIDs {
+				templateData.HighlightObjects[highlightID] = notes.ObjectLink(bookRoute.spaceID, objectID)
+			}
+			if err := s.linkHighlightObjects(bookRoute, obj.ID, templateData, objectIDs); err != nil {
+				return err
+			}
 		}
 	}
 
 	return nil
 }
+
+// resolveRoute applies the routing rules to a book, falling back to the global configuration
+func (s *Syncer) resolveRoute(book bookmarks.ReadwiseBook, defaultSpaceID string) route {
+	bookRoute := route{
+		spaceID: defaultSpaceID,
+		objectOptions: notes.BookObjectOptions{
+			ObjectType: s.config.ObjectType,
+			Icon:       s.config.Icons[strings.ToLower(book.Category)],
+		},
+		templateProvider: s.templateProvider,
+		nameRenderer:     s.nameRenderer,
+		collection:       s.config.Collection,
+	}
+
+	rule := s.router.Match(book)
+	if rule == nil {
+		return bookRoute
+	}
+
+	if rule.Name != "" {
+		fmt.Println("Matched routing rule:", rule.Name)
+	}
+	bookRoute.skip = rule.Skip
+	if rule.SpaceID != "" {
+		bookRoute.spaceID = rule.SpaceID
+	}
+	if rule.ObjectType != "" {
+		bookRoute.objectOptions.ObjectType = rule.ObjectType
+	}
+	if rule.Icon != "" {
+		bookRoute.objectOptions.Icon = rule.Icon
+	}
+	if provider := s.ruleTemplateProvider(rule); provider != nil {
+		bookRoute.templateProvider = provider
+	}
+	if rule.Collection != "" {
+		bookRoute.collection = rule.Collection
+	}
+	if renderer, ok := s.ruleNames[rule.NamePattern]; ok {
+		bookRoute.nameRenderer = renderer
+	}
+
+	return bookRoute
+}
+
+// ruleTemplateProvider returns the template provider configured by a rule, or nil to use the default one
+func (s *Syncer) ruleTemplateProvider(rule *core.RoutingRule) templates.TemplateProvider {
+	var key string
+	switch {
+	case rule.AnytypeTemplateID != "":
+		key = "anytype:" + rule.AnytypeTemplateID
+	case rule.TemplatePath != "":
+		key = "markdown:" + rule.TemplatePath
+	default:
+		return nil
+	}
+
+	if provider, ok := s.ruleTemplates[key]; ok {
+		return provider
+	}
+
+	var provider templates.TemplateProvider
+	if rule.AnytypeTemplateID != "" {
+		provider = templates.NewAnytypeTemplateProvider(s.anytypeClient, rule.AnytypeTemplateID)
+	} else {
+		provider = templates.NewMarkdownTemplateProvider(rule.TemplatePath)
+	}
+	s.ruleTemplates[key] = provider
+	return provider
+}
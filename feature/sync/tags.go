@@ -0,0 +1,157 @@
+package sync
+
+import (
+	"anytype-readwise/feature/bookmarks"
+	"anytype-readwise/feature/notes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// spaceTags holds the tags of the tag property of a space, loaded once per sync
+type spaceTags struct {
+	propertyID string
+	names      map[string]string // tag ID -> name
+	byName     map[string]string // lowercased name -> tag ID
+}
+
+// tagIDs returns the Anytype tags matching the Readwise tags, creating missing tags
+// and renaming the ones renamed in Readwise
+func (s *Syncer) tagIDs(spaceID string, tags []bookmarks.Tag) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	known, err := s.spaceTags(spaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		name := strings.TrimSpace(tag.Name)
+		if name == "" {
+			continue
+		}
+
+		tagID, err := s.tagID(spaceID, known, tag, name)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[tagID] {
+			seen[tagID] = true
+			ids = append(ids, tagID)
+		}
+	}
+	return ids, nil
+}
+
+func (s *Syncer) tagID(spaceID string, known *spaceTags, tag bookmarks.Tag, name string) (string, error) {
+	key := readwiseTagKey(tag)
+
+	// Tags already synced keep their Anytype tag, which is renamed along with the Readwise tag
+	if tagID, ok := s.state.TagID(spaceID, key); ok {
+		if current, exists := known.names[tagID]; exists {
+			if current != name {
+				fmt.Printf("Renaming tag %q to %q\n", current, name)
+				err := withRetry("rename tag", func() error {
+					return s.anytypeClient.RenameTag(spaceID, known.propertyID, tagID, name)
+				})
+				if err != nil {
+					return "", fmt.Errorf("failed to rename tag %s: %w", current, err)
+				}
+				delete(known.byName, strings.ToLower(current))
+				known.names[tagID] = name
+				known.byName[strings.ToLower(name)] = tagID
+			}
+			return tagID, nil
+		}
+	}
+
+	tagID, ok := known.byName[strings.ToLower(name)]
+	if !ok {
+		var created *notes.AnytypeTag
+		err := withRetry("create tag", func() error {
+			var err error
+			created, err = s.anytypeClient.CreateTag(spaceID, known.propertyID, name)
+			return err
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to create tag %s: %w", name, err)
+		}
+		fmt.Printf("Created tag: %s\n", name)
+		tagID = created.ID
+		known.names[tagID] = name
+		known.byName[strings.ToLower(name)] = tagID
+	}
+
+	s.state.SetTagID(spaceID, key, tagID)
+	return tagID, nil
+}
+
+// spaceTags loads the tag property of a space and its tags
+func (s *Syncer) spaceTags(spaceID string) (*spaceTags, error) {
+	if known, ok := s.tagCache[spaceID]; ok {
+		return known, nil
+	}
+
+	var property *notes.AnytypeProperty
+	err := withRetry("get tag property", func() error {
+		var err error
+		property, err = s.anytypeClient.GetProperty(spaceID, s.tagProperty())
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tag property: %w", err)
+	}
+
+	var tags []notes.AnytypeTag
+	err = withRetry("get tags", func() error {
+		var err error
+		tags, err = s.anytypeClient.GetTags(spaceID, property.ID)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+
+	known := &spaceTags{
+		propertyID: property.ID,
+		names:      make(map[string]string, len(tags)),
+		byName:     make(map[string]string, len(tags)),
+	}
+	for _, tag := range tags {
+		known.names[tag.ID] = tag.Name
+		known.byName[strings.ToLower(tag.Name)] = tag.ID
+	}
+	s.tagCache[spaceID] = known
+	return known, nil
+}
+
+// readwiseTagKey identifies a Readwise tag by ID, or by name for tags without ID
+func readwiseTagKey(tag bookmarks.Tag) string {
+	if tag.ID != 0 {
+		return strconv.Itoa(tag.ID)
+	}
+	return "name:" + strings.ToLower(strings.TrimSpace(tag.Name))
+}
+
+// bookTags returns the tags of the book object: its own tags, plus the tags of its
+// highlights when they are not synced as separate objects
+func (s *Syncer) bookTags(book bookmarks.ReadwiseBook, highlights []bookmarks.Highlight) []bookmarks.Tag {
+	tags := append([]bookmarks.Tag{}, book.AllTags()...)
+	if !s.config.HighlightObjects {
+		for _, highlight := range highlights {
+			tags = append(tags, highlight.Tags...)
+		}
+	}
+	return tags
+}
+
+func (s *Syncer) tagProperty() string {
+	if s.config.TagProperty != "" {
+		return s.config.TagProperty
+	}
+	return "tag"
+}
@@ -3,6 +3,7 @@ package templates
 import (
 	"anytype-readwise/feature/notes"
 	"fmt"
+	"strings"
 )
 
 // AnytypeTemplateProvider implements TemplateProvider using an Anytype template
@@ -35,6 +36,10 @@ func (p *AnytypeTemplateProvider) Render(data TemplateData) (string, error) {
 	templateStr += "## Highlights\n\n"
 
 	for i, highlight := range data.Highlights {
+		if highlight.HeadingLevel > 0 {
+			templateStr += fmt.Sprintf("%s %s\n\n", strings.Repeat("#", highlight.HeadingLevel+2), highlight.Text)
+			continue
+		}
 		templateStr += fmt.Sprintf("### Highlight %d\n\n", i+1)
 		templateStr += fmt.Sprintf("%s\n\n", highlight.Text)
 		if highlight.Note != "" {
@@ -27,15 +27,8 @@ func (p *MarkdownTemplateProvider) Render(data TemplateData) (string, error) {
 		return "", fmt.Errorf("failed to read template file: %w", err)
 	}
 
-	// Define custom functions
-	funcMap := template.FuncMap{
-		"add": func(a, b int) int {
-			return a + b
-		},
-	}
-
 	// Parse the template
-	tmpl, err := template.New("book").Funcs(funcMap).Parse(string(templateContent))
+	tmpl, err := template.New("book").Funcs(templateFuncs()).Parse(string(templateContent))
 	if err != nil {
 		return "", fmt.Errorf("failed to parse template: %w", err)
 	}
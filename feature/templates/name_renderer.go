@@ -0,0 +1,40 @@
+package templates
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// DefaultNamePattern is the object name used when no pattern is configured
+const DefaultNamePattern = "{{.Book.DefaultName}}"
+
+// NameRenderer renders object names from a Go template pattern
+type NameRenderer struct {
+	tmpl *template.Template
+}
+
+// NewNameRenderer parses the given pattern, falling back to DefaultNamePattern when empty
+func NewNameRenderer(pattern string) (*NameRenderer, error) {
+	if pattern == "" {
+		pattern = DefaultNamePattern
+	}
+
+	tmpl, err := template.New("name").Funcs(templateFuncs()).Parse(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse name pattern: %w", err)
+	}
+
+	return &NameRenderer{tmpl: tmpl}, nil
+}
+
+// Render renders the object name for the given data
+func (r *NameRenderer) Render(data TemplateData) (string, error) {
+	var buf strings.Builder
+	if err := r.tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to execute name pattern: %w", err)
+	}
+
+	// Names are single line, collapse any whitespace the pattern may have produced
+	return strings.Join(strings.Fields(buf.String()), " "), nil
+}
@@ -0,0 +1,33 @@
+package templates
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// PropertyRenderer renders the value of a mapped property from a Go template
+type PropertyRenderer struct {
+	Key    string
+	Format string
+	tmpl   *template.Template
+}
+
+// NewPropertyRenderer parses the value template of a property mapping
+func NewPropertyRenderer(key, format, value string) (*PropertyRenderer, error) {
+	tmpl, err := template.New(key).Funcs(templateFuncs()).Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse value of property %s: %w", key, err)
+	}
+
+	return &PropertyRenderer{Key: key, Format: format, tmpl: tmpl}, nil
+}
+
+// Render renders the property value for the given data
+func (r *PropertyRenderer) Render(data TemplateData) (string, error) {
+	var buf strings.Builder
+	if err := r.tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to execute value of property %s: %w", r.Key, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
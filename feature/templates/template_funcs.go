@@ -0,0 +1,37 @@
+package templates
+
+import (
+	"anytype-readwise/core"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs returns the helper functions available to every template
+func templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"add": func(a, b int) int {
+			return a + b
+		},
+		"repeat": func(count int, s string) string {
+			return strings.Repeat(s, count)
+		},
+		"truncate":      truncate,
+		"stripSubtitle": stripSubtitle,
+	}
+}
+
+// truncate shortens s to at most n characters, taking the value last as pipelines do
+func truncate(n int, s string) string {
+	return core.Truncate(s, n)
+}
+
+// stripSubtitle removes everything after the first subtitle separator of a title
+func stripSubtitle(title string) string {
+	cut := len(title)
+	for _, sep := range []string{": ", " - ", " — ", " – ", " ("} {
+		if i := strings.Index(title, sep); i > 0 && i < cut {
+			cut = i
+		}
+	}
+	return strings.TrimSpace(title[:cut])
+}
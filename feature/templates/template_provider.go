@@ -2,6 +2,7 @@ package templates
 
 import (
 	"anytype-readwise/feature/bookmarks"
+	"anytype-readwise/feature/state"
 )
 
 // TemplateData contains the data needed to render a template
@@ -9,6 +10,12 @@ type TemplateData struct {
 	Book       bookmarks.ReadwiseBook
 	Highlights []bookmarks.Highlight
 	SyncDate   string
+	// Authors are the normalized names of the book authors
+	Authors []string
+	// HighlightObjects maps highlight IDs to the link of their own object, when highlights are synced as objects
+	HighlightObjects map[int]string
+	// History maps the IDs of the highlights edited since they were first synced to their previous versions
+	History map[int]*state.HighlightRecord
 }
 
 // TemplateProvider is an interface for rendering templates
@@ -2,4 +2,4 @@ module anytype-readwise
 
 go 1.23.1
 
-require github.com/joho/godotenv v1.5.1 // indirect
+require github.com/joho/godotenv v1.5.1
@@ -11,43 +11,94 @@ import (
 	"github.com/joho/godotenv"
 	"log"
 	"os"
+	"path/filepath"
 )
 
+// defaultStatePath is the state file of the Anytype sink, the markdown sink keeps it in its output directory
+const defaultStatePath = ".anytype-readwise-state.json"
+
 func main() {
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found")
 	}
 
+	// Initialize configuration
+	config := &core.Config{
+		ReadwiseToken:  os.Getenv("READWISE_TOKEN"),
+		AnytypeAPIKey:  os.Getenv("ANYTYPE_API_KEY"),
+		AnytypeBaseURL: core.GetEnvOrDefault("ANYTYPE_API_BASE_URL", "http://localhost:31009"),
+		AnytypeVersion: core.GetEnvOrDefault("ANYTYPE_VERSION", "2025-05-20"),
+	}
+
 	// Command line flags
-	templatePath := flag.String("template", "book_template.md", "Path to markdown template file")
-	anytypeTemplateID := flag.String("anytype-template", "", "Anytype template ID (optional)")
-	objectType := flag.String("type", "Bookmark", "Anytype object type to create")
-	spaceID := flag.String("space", "", "Anytype space ID (optional)")
+	flag.StringVar(&config.Source, "source", core.SourceReadwise, "Where to read books and highlights from: readwise, readwise-csv, kindle, koreader, kobo, hypothesis, zotero, pdf, json, mirror or composite")
+	flag.StringVar(&config.SourcePath, "source-path", "", "File or directory read by local sources, e.g. the Kindle \"My Clippings.txt\" a KOReader books directory, KoboReader.sqlite, a Readwise CSV export, a JSON export, a directory of PDFs or - for standard input")
+	flag.StringVar(&config.TemplatePath, "template", "book_template.md", "Path to markdown template file")
+	flag.StringVar(&config.AnytypeTemplateID, "anytype-template", "", "Anytype template ID (optional)")
+	flag.StringVar(&config.ObjectType, "type", "Bookmark", "Anytype object type to create")
+	flag.StringVar(&config.SpaceID, "space", "", "Anytype space ID (optional)")
+	flag.StringVar(&config.NamePattern, "name", templates.DefaultNamePattern, "Go template used to name the created objects")
+	flag.StringVar(&config.StatePath, "state", defaultStatePath, "Path to the local sync state file")
+	flag.StringVar(&config.CoverImage, "cover-image", "", "Where to set the book cover image: icon, cover or both (optional)")
+	flag.StringVar(&config.OnDelete, "on-delete", "", "What to do with objects of books deleted in Readwise: archive or flag (optional)")
+	flag.BoolVar(&config.HighlightObjects, "highlight-objects", false, "Create one Anytype object per highlight, linked to its book")
+	flag.BoolVar(&config.AuthorObjects, "author-objects", false, "Create one Anytype object per author and link the books to them")
+	flag.StringVar(&config.Collection, "collection", "", "Name of the collection synced objects are added to, created if missing (optional)")
+	flag.BoolVar(&config.SyncTags, "sync-tags", false, "Sync the Readwise tags into the Anytype tag property")
+	flag.BoolVar(&config.NoteConventions, "note-conventions", false, "Interpret the Readwise note conventions (.h1, .tag, .c1) in highlight notes")
+	flag.StringVar(&config.Sink, "sink", core.SinkAnytype, "Where to write the rendered books: anytype, markdown or anytype-import")
+	flag.StringVar(&config.OutputDir, "output-dir", "", "Directory the markdown sink writes to")
+	flag.StringVar(&config.Mirror, "mirror", "", "Directory where every book and highlight read is archived, with edits and deletions (optional)")
+	flag.StringVar(&config.EditedSince, "edited-since", "", "List the highlights edited since this date (YYYY-MM-DD) in the report (optional)")
+	flag.StringVar(&config.BundlePath, "bundle", "", "Zip file the anytype-import sink writes to")
+	configPath := flag.String("config", "", "Path to a JSON configuration file with routing rules (optional)")
 	flag.Parse()
 
-	// Initialize configuration
-	config := &core.Config{
-		ReadwiseToken:     os.Getenv("READWISE_TOKEN"),
-		AnytypeAPIKey:     os.Getenv("ANYTYPE_API_KEY"),
-		AnytypeBaseURL:    core.GetEnvOrDefault("ANYTYPE_API_BASE_URL", "http://localhost:31009"),
-		AnytypeVersion:    core.GetEnvOrDefault("ANYTYPE_VERSION", "2025-05-20"),
-		TemplatePath:      *templatePath,
-		AnytypeTemplateID: *anytypeTemplateID,
-		ObjectType:        *objectType,
-		SpaceID:           *spaceID,
+	if *configPath != "" {
+		if err := loadConfigFile(*configPath, config); err != nil {
+			log.Fatal("Configuration error:", err)
+		}
 	}
 
 	if err := core.ValidateConfig(config); err != nil {
 		log.Fatal("Configuration error:", err)
 	}
+	if config.Sink == core.SinkMarkdown {
+		// The markdown directory keeps its own state
+		if config.StatePath == defaultStatePath {
+			config.StatePath = filepath.Join(config.OutputDir, defaultStatePath)
+		}
+	}
+	if config.Sink == core.SinkAnytypeImport && config.StatePath == defaultStatePath {
+		// The bundle is a snapshot, its state must not be mixed with the objects synced through the API
+		config.StatePath = ""
+	}
 
 	// Initialize services
-	// Create a BookmarksProvider (ReadwiseClient)
-	bookmarksProvider := bookmarks.NewReadwiseClient(config.ReadwiseToken)
+	// Create a BookmarksProvider for the configured source
+	bookmarksProvider := newBookmarksProvider(config)
 
-	// Create an AnytypeClient
-	anytypeClient := notes.NewAnytypeClient(config.AnytypeAPIKey, config.AnytypeBaseURL, config.AnytypeVersion, config)
+	// Create the sink rendered books are written to
+	var sink notes.Sink
+	var anytypeClient *notes.AnytypeClient
+	var bundle *notes.ImportBundle
+	switch config.Sink {
+	case core.SinkMarkdown:
+		fmt.Println("Writing markdown files to:", config.OutputDir)
+		// The state is saved in the directory even when no book was written
+		if err := os.MkdirAll(config.OutputDir, 0o755); err != nil {
+			log.Fatal("Failed to create output directory:", err)
+		}
+		sink = notes.NewMarkdownSink(config.OutputDir)
+	case core.SinkAnytypeImport:
+		fmt.Println("Writing Anytype import bundle to:", config.BundlePath)
+		bundle = notes.NewImportBundle(config.BundlePath, config.Collection)
+		sink = bundle
+	default:
+		anytypeClient = notes.NewAnytypeClient(config.AnytypeAPIKey, config.AnytypeBaseURL, config.AnytypeVersion, config)
+		sink = anytypeClient
+	}
 
 	// Create a TemplateProvider based on configuration
 	var templateProvider templates.TemplateProvider
@@ -62,10 +113,109 @@ func main() {
 	}
 
 	// Create syncer and run
-	syncer := sync.NewSyncer(bookmarksProvider, anytypeClient, templateProvider, config)
+	syncer, err := sync.NewSyncer(bookmarksProvider, sink, templateProvider, config)
+	if err != nil {
+		log.Fatal("Configuration error:", err)
+	}
 	if err := syncer.Sync(); err != nil {
 		log.Fatal("Sync failed:", err)
 	}
+	if bundle != nil {
+		if err := bundle.Close(); err != nil {
+			log.Fatal("Export failed:", err)
+		}
+	}
 
 	fmt.Println("Sync completed successfully!")
 }
+
+// newBookmarksProvider creates the BookmarksProvider of the configured source, recorded into the mirror if any
+func newBookmarksProvider(config *core.Config) bookmarks.BookmarksProvider {
+	provider := newConfiguredProvider(config)
+	if config.Mirror == "" || config.Source == core.SourceMirror {
+		return provider
+	}
+
+	fmt.Println("Mirroring books and highlights to:", config.Mirror)
+	source := config.Source
+	if source == "" {
+		source = core.SourceReadwise
+	}
+	return bookmarks.NewMirroredProvider(provider, bookmarks.NewMirror(config.Mirror), source)
+}
+
+// newConfiguredProvider creates the BookmarksProvider of the configured source, or of the merged sources
+func newConfiguredProvider(config *core.Config) bookmarks.BookmarksProvider {
+	if config.Source != core.SourceComposite {
+		return newSourceProvider(config, config.Source, config.SourcePath)
+	}
+
+	sources := make([]bookmarks.CompositeSource, 0, len(config.Sources))
+	for _, source := range config.Sources {
+		name := source.Type
+		if name == "" {
+			name = core.SourceReadwise
+		}
+		sources = append(sources, bookmarks.CompositeSource{
+			Name:     name,
+			Provider: newSourceProvider(config, source.Type, source.Path),
+		})
+	}
+	fmt.Println("Merging books and highlights from", len(sources), "sources")
+	return bookmarks.NewCompositeProvider(sources...)
+}
+
+// newSourceProvider creates the BookmarksProvider of a single source
+func newSourceProvider(config *core.Config, source, path string) bookmarks.BookmarksProvider {
+	switch source {
+	case core.SourceKindleClippings:
+		fmt.Println("Reading Kindle clippings from:", path)
+		return bookmarks.NewKindleClippingsProvider(path)
+	case core.SourceKOReader:
+		fmt.Println("Reading KOReader annotations from:", path)
+		return bookmarks.NewKOReaderProvider(path)
+	case core.SourceKobo:
+		fmt.Println("Reading Kobo annotations from:", path)
+		return bookmarks.NewKoboProvider(path)
+	case core.SourceReadwiseCSV:
+		fmt.Println("Reading Readwise CSV export from:", path)
+		return bookmarks.NewReadwiseCSVProvider(path)
+	case core.SourceHypothesis:
+		fmt.Println("Reading web annotations from:", path)
+		return bookmarks.NewWebAnnotationsProvider(path)
+	case core.SourceZotero:
+		fmt.Println("Reading Zotero export from:", path)
+		return bookmarks.NewZoteroProvider(path)
+	case core.SourcePDF:
+		fmt.Println("Reading PDF annotations from:", path)
+		return bookmarks.NewPDFAnnotationsProvider(path)
+	case core.SourceJSON:
+		fmt.Println("Reading JSON records from:", path)
+		return bookmarks.NewJSONProvider(path)
+	case core.SourceMirror:
+		fmt.Println("Reading the mirror from:", path)
+		return bookmarks.NewMirrorProvider(path)
+	default:
+		return bookmarks.NewReadwiseClient(config.ReadwiseToken)
+	}
+}
+
+// loadConfigFile applies the configuration file on top of the flags.
+// Flags that were set explicitly on the command line keep precedence over the file.
+func loadConfigFile(path string, config *core.Config) error {
+	explicit := make(map[string]string)
+	flag.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = f.Value.String()
+	})
+
+	if err := core.LoadConfigFile(path, config); err != nil {
+		return err
+	}
+
+	for name, value := range explicit {
+		if err := flag.Set(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}